@@ -1,5 +1,11 @@
 package conduit
 
+import (
+	"context"
+	"strings"
+	"time"
+)
+
 type Tag struct {
 	ID   uint
 	Name string
@@ -11,3 +17,82 @@ type TagFilter struct {
 	Limit  int
 	Offset int
 }
+
+const (
+	TagActionRename      = "rename"
+	TagActionMerge       = "merge"
+	TagActionAddAlias    = "add_alias"
+	TagActionRemoveAlias = "remove_alias"
+	TagActionDelete      = "delete"
+)
+
+// TagAuditEntry records a moderator action on a tag.
+type TagAuditEntry struct {
+	ID          uint      `json:"id"`
+	Action      string    `json:"action"`
+	TagName     string    `json:"tag" db:"tag_name"`
+	TargetName  string    `json:"target,omitempty" db:"target_name"`
+	ModeratorID *uint     `json:"-" db:"moderator_id"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+type TagAuditFilter struct {
+	TagName *string
+
+	Limit  int
+	Offset int
+}
+
+type TagService interface {
+	// SuggestTags returns tags starting with or resembling prefix, best match first.
+	SuggestTags(ctx context.Context, prefix string, limit int) ([]*Tag, error)
+
+	// SimilarTags returns existing tags that are close to name. It returns
+	// nothing when name is already a tag.
+	SimilarTags(ctx context.Context, name string) ([]*Tag, error)
+
+	RenameTag(ctx context.Context, moderator *User, name, newName string) (*Tag, error)
+
+	// MergeTags moves every article from tag `from` to tag `into`, deletes
+	// `from` and keeps its name as an alias of `into`.
+	MergeTags(ctx context.Context, moderator *User, from, into string) (*Tag, error)
+
+	AddTagAlias(ctx context.Context, moderator *User, name, alias string) error
+
+	RemoveTagAlias(ctx context.Context, moderator *User, alias string) error
+
+	// DeleteTag deletes a tag that no article uses.
+	DeleteTag(ctx context.Context, moderator *User, name string) error
+
+	// DeleteUnusedTags deletes every tag no article uses and returns how many went.
+	DeleteUnusedTags(ctx context.Context, moderator *User) (int64, error)
+
+	TagAuditLog(context.Context, TagAuditFilter) ([]*TagAuditEntry, error)
+
+	FollowTag(ctx context.Context, user *User, name string) (*Tag, error)
+
+	UnfollowTag(ctx context.Context, user *User, name string) (*Tag, error)
+}
+
+// NormalizeTagName trims the name, collapses inner whitespace to a single
+// space and lowercases it, so "  Go  Lang" and "go lang" are the same tag.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// NormalizeTagNames normalizes every name, dropping empty names and duplicates.
+func NormalizeTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+
+	for _, n := range names {
+		n = NormalizeTagName(n)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		normalized = append(normalized, n)
+	}
+
+	return normalized
+}
@@ -6,20 +6,91 @@ import (
 )
 
 type Article struct {
-	ID             uint      `json:"-"`
-	Title          string    `json:"title"`
-	Body           string    `json:"body"`
-	Description    string    `json:"description"`
-	Favorited      bool      `json:"favorited"`
-	FavoritesCount int64     `json:"favoritesCount" db:"favorites_count"`
-	FavoritedBy    []*User   `json:"-"`
-	Slug           string    `json:"slug"`
-	AuthorID       uint      `json:"-" db:"author_id"`
-	Author         *User     `json:"-"`
-	AuthorProfile  *Profile  `json:"author"`
-	Tags           []*Tag    `json:"tagList"`
-	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+	ID               uint           `json:"-"`
+	Title            string         `json:"title"`
+	Body             string         `json:"body"`
+	BodyHTML         string         `json:"bodyHtml,omitempty" db:"body_html"`
+	Description      string         `json:"description"`
+	Favorited        bool           `json:"favorited"`
+	Bookmarked       bool           `json:"bookmarked"`
+	FavoritesCount   int64          `json:"favoritesCount" db:"favorites_count"`
+	FavoritedBy      []*User        `json:"-"`
+	Slug             string         `json:"slug"`
+	AuthorID         uint           `json:"-" db:"author_id"`
+	Author           *User          `json:"-"`
+	AuthorProfile    *Profile       `json:"author"`
+	CoAuthors        []*User        `json:"-"`
+	CoAuthorProfiles []*Profile     `json:"coAuthors"`
+	Tags             []*Tag         `json:"tagList"`
+	Series           *ArticleSeries `json:"series,omitempty"`
+	Reactions        *Reactions     `json:"reactions"`
+	Highlight        string         `json:"highlight,omitempty"`
+	Rank             float64        `json:"-"`
+	Status           string         `json:"status"`
+	PublishedAt      *time.Time     `json:"publishedAt,omitempty" db:"published_at"`
+	PublishAt        *time.Time     `json:"publishAt,omitempty" db:"publish_at"`
+	WordCount        int            `json:"wordCount" db:"word_count"`
+	ReadingTime      int            `json:"readingTimeMinutes" db:"reading_time_minutes"`
+	CreatedAt        time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusPublished = "published"
+	ArticleStatusScheduled = "scheduled"
+	ArticleStatusArchived  = "archived"
+)
+
+// SetStatus moves the article to status. Scheduling needs a publishAt in the
+// future, and publishing records the first time the article went public.
+func (a *Article) SetStatus(status string, publishAt *time.Time, now time.Time) error {
+	switch status {
+	case ArticleStatusDraft, ArticleStatusArchived:
+		a.PublishAt = nil
+	case ArticleStatusPublished:
+		a.PublishAt = nil
+		if a.PublishedAt == nil {
+			a.PublishedAt = &now
+		}
+	case ArticleStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return ErrInvalidPublishAt
+		}
+		a.PublishAt = publishAt
+	default:
+		return ErrInvalidArticleStatus
+	}
+
+	a.Status = status
+
+	return nil
+}
+
+func (a *Article) IsPublished() bool {
+	return a.Status == ArticleStatusPublished
+}
+
+// IsOwnedBy reports whether user wrote the article. Only the owner may delete
+// it or manage its co-authors.
+func (a *Article) IsOwnedBy(user *User) bool {
+	return a.AuthorID == user.ID
+}
+
+// CanEdit reports whether user may change the article and see its revisions,
+// which the owner and every accepted co-author may.
+func (a *Article) CanEdit(user *User) bool {
+	if a.IsOwnedBy(user) {
+		return true
+	}
+
+	for _, u := range a.CoAuthors {
+		if u.ID == user.ID {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (a *Article) SetAuthorProfile(currentUser *User) {
@@ -30,6 +101,20 @@ func (a *Article) SetAuthorProfile(currentUser *User) {
 	}
 
 	a.AuthorProfile.Following = currentUser.IsFollowing(currentUser)
+
+	a.CoAuthorProfiles = make([]*Profile, len(a.CoAuthors))
+	for i, u := range a.CoAuthors {
+		a.CoAuthorProfiles[i] = &Profile{
+			Username:  u.Username,
+			Bio:       u.Bio,
+			Image:     u.Image,
+			Following: currentUser.IsFollowing(u),
+		}
+	}
+}
+
+func (a *Article) ReactionTarget() ReactionTarget {
+	return ReactionTarget{ArticleID: a.ID}
 }
 
 func (a *Article) UserHasFavorite(currentUser *User) bool {
@@ -44,6 +129,7 @@ func (a *Article) UserHasFavorite(currentUser *User) bool {
 
 type ArticleFilter struct {
 	ID             *uint
+	IDs            []uint
 	Title          *string
 	Description    *string
 	AuthorID       *uint
@@ -51,19 +137,91 @@ type ArticleFilter struct {
 	Tag            *string
 	Slug           *string
 	FavoritedBy    *string
+	SeriesSlug     *string
+	Query          *string
+
+	// BookmarkedBy restricts the results to the user's bookmarks, most
+	// recently saved first, optionally within BookmarkFolder.
+	BookmarkedBy   *uint
+	BookmarkFolder *string
+
+	// PublishedSince restricts the results to articles first published
+	// after it.
+	PublishedSince *time.Time
+
+	FeedSource string
+	Sort       string
+
+	// Status restricts the results to one status. When nil only published
+	// articles are returned, unless AnyStatus is set.
+	Status    *string
+	AnyStatus bool
 
 	Limit  int
 	Offset int
 }
 
+// Feed sources restrict ArticleFeed to one kind of following. The zero value
+// includes both followed authors and followed tags.
+const (
+	FeedSourceAuthors = "authors"
+	FeedSourceTags    = "tags"
+)
+
+// Sort orders for ArticleFilter. The zero value sorts newest first.
+const (
+	SortReadingTime     = "readingTime"
+	SortReadingTimeDesc = "-readingTime"
+	SortFavoritesDesc   = "-favorites"
+)
+
+type ArticlePatch struct {
+	Title       *string
+	Body        *string
+	BodyHTML    *string
+	WordCount   *int
+	Description *string
+	Tags        []string
+	Status      *string
+	PublishAt   *time.Time
+
+	// Editor is recorded as the author of the revision the patch creates.
+	Editor *User
+}
+
+// Renderer turns an article body into sanitized HTML.
+type Renderer interface {
+	Render(body string) (string, error)
+
+	// PlainText returns the body with its markup and code blocks stripped,
+	// with paragraphs separated by blank lines.
+	PlainText(body string) string
+}
+
 type ArticleService interface {
 	CreateArticle(context.Context, *Article) error
+	ArticleBySlug(context.Context, string) (*Article, error)
+
+	// CurrentSlug returns the slug of the article that used to be reachable
+	// under previousSlug before its title changed.
+	CurrentSlug(ctx context.Context, previousSlug string) (string, error)
 	Articles(context.Context, ArticleFilter) ([]*Article, error)
 	ArticleFeed(context.Context, *User, ArticleFilter) ([]*Article, error)
+	UpdateArticle(context.Context, *Article, ArticlePatch) error
+	DeleteArticle(context.Context, *Article) error
+	FavoriteArticle(ctx context.Context, user *User, article *Article) error
+	UnfavoriteArticle(ctx context.Context, user *User, article *Article) error
+
+	ArticleRevisions(context.Context, *Article) ([]*ArticleRevision, error)
+	ArticleRevision(ctx context.Context, article *Article, number int) (*ArticleRevision, error)
+
+	// PublishScheduledArticles publishes every scheduled article whose
+	// publish time is not after now and returns them.
+	PublishScheduledArticles(ctx context.Context, now time.Time) ([]*Article, error)
 }
 
 func (a *Article) AddTags(_tags ...string) {
-	for _, t := range _tags {
+	for _, t := range NormalizeTagNames(_tags) {
 		a.Tags = append(a.Tags, &Tag{Name: t})
 	}
 }
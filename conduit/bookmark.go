@@ -0,0 +1,23 @@
+package conduit
+
+import "context"
+
+// BookmarkFolder is one of the folders a user files bookmarks under. The
+// unnamed folder holds bookmarks saved without one.
+type BookmarkFolder struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// BookmarkService keeps each user's private read-later list. Unlike
+// favorites, bookmarks are never shown to anyone but their owner.
+type BookmarkService interface {
+	// BookmarkArticle saves article for user, moving it to folder when it
+	// is already bookmarked.
+	BookmarkArticle(ctx context.Context, user *User, article *Article, folder string) error
+	UnbookmarkArticle(ctx context.Context, user *User, article *Article) error
+	BookmarkFolders(ctx context.Context, user *User) ([]*BookmarkFolder, error)
+
+	// SetBookmarked sets the Bookmarked flag of each article user saved.
+	SetBookmarked(ctx context.Context, user *User, articles ...*Article) error
+}
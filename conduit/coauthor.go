@@ -0,0 +1,43 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	CoAuthorInvited  = "invited"
+	CoAuthorAccepted = "accepted"
+)
+
+// CoAuthorInvitation asks a user to become a co-author of an article. The
+// user only becomes a co-author once they accept it.
+type CoAuthorInvitation struct {
+	ArticleID    uint       `json:"-" db:"article_id"`
+	ArticleSlug  string     `json:"slug" db:"article_slug"`
+	ArticleTitle string     `json:"title" db:"article_title"`
+	UserID       uint       `json:"-" db:"user_id"`
+	Username     string     `json:"username"`
+	InvitedByID  uint       `json:"-" db:"invited_by"`
+	InvitedBy    string     `json:"invitedBy" db:"invited_by_username"`
+	Status       string     `json:"status"`
+	InvitedAt    time.Time  `json:"invitedAt" db:"invited_at"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty" db:"accepted_at"`
+}
+
+type CoAuthorService interface {
+	InviteCoAuthor(ctx context.Context, article *Article, user, invitedBy *User) (*CoAuthorInvitation, error)
+
+	// AcceptCoAuthorInvitation makes user a co-author of the article, placed
+	// after the existing co-authors.
+	AcceptCoAuthorInvitation(ctx context.Context, article *Article, user *User) error
+
+	// RemoveCoAuthor removes a co-author or withdraws or declines an invitation.
+	RemoveCoAuthor(ctx context.Context, article *Article, user *User) error
+
+	// ReorderCoAuthors sets the order of the accepted co-authors, which must
+	// all be listed.
+	ReorderCoAuthors(ctx context.Context, article *Article, userIDs []uint) error
+
+	CoAuthorInvitations(ctx context.Context, user *User) ([]*CoAuthorInvitation, error)
+}
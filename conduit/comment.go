@@ -0,0 +1,117 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultCommentMaxDepth is how deeply replies may nest when no other limit
+// is configured. Top-level comments have depth 0.
+const DefaultCommentMaxDepth = 5
+
+// CommentDeletedBody replaces the body of a deleted comment that is kept as
+// a placeholder because it still has replies.
+const CommentDeletedBody = "[deleted]"
+
+type Comment struct {
+	ID            uint       `json:"id"`
+	ArticleID     uint       `json:"-" db:"article_id"`
+	ParentID      *uint      `json:"parentId" db:"parent_id"`
+	Depth         int        `json:"depth"`
+	Body          string     `json:"body"`
+	AuthorID      uint       `json:"-" db:"author_id"`
+	Author        *User      `json:"-"`
+	AuthorProfile *Profile   `json:"author"`
+	Deleted       bool       `json:"deleted" db:"-"`
+	DeletedAt     *time.Time `json:"-" db:"deleted_at"`
+	Reactions     *Reactions `json:"reactions"`
+	Replies       []*Comment `json:"replies,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
+
+func (c *Comment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+func (c *Comment) ReactionTarget() ReactionTarget {
+	return ReactionTarget{ArticleID: c.ArticleID, CommentID: c.ID}
+}
+
+// SetAuthorProfile fills in the author block, or blanks the comment out when
+// it is only kept as a placeholder for its replies.
+func (c *Comment) SetAuthorProfile(currentUser *User) {
+	if c.IsDeleted() {
+		c.Deleted = true
+		c.Body = CommentDeletedBody
+		c.AuthorProfile = nil
+		return
+	}
+
+	c.AuthorProfile = &Profile{
+		Username:  c.Author.Username,
+		Bio:       c.Author.Bio,
+		Image:     c.Author.Image,
+		Following: currentUser.IsFollowing(c.Author),
+	}
+}
+
+// CommentTree nests comments under their parents and returns the top-level
+// ones. comments must hold whole threads, as returned for one article, and
+// keep their order within each level.
+func CommentTree(comments []*Comment) []*Comment {
+	byID := make(map[uint]*Comment, len(comments))
+	for _, c := range comments {
+		c.Replies = nil
+		byID[c.ID] = c
+	}
+
+	roots := make([]*Comment, 0)
+	for _, c := range comments {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+
+		if parent, ok := byID[*c.ParentID]; ok {
+			parent.Replies = append(parent.Replies, c)
+		}
+	}
+
+	return roots
+}
+
+const (
+	CommentCreated = "comment.created"
+	CommentDeleted = "comment.deleted"
+)
+
+// CommentEvent reports a comment being added to or removed from an article.
+// Comment is only set for CommentCreated.
+type CommentEvent struct {
+	Kind      string   `json:"type"`
+	ArticleID uint     `json:"-"`
+	CommentID uint     `json:"commentId"`
+	Comment   *Comment `json:"comment,omitempty"`
+}
+
+type CommentFilter struct {
+	ID        *uint
+	ArticleID *uint
+
+	Limit  int
+	Offset int
+}
+
+type CommentService interface {
+	// CreateComment stores a comment, or a reply when ParentID is set. It
+	// fails with ErrCommentTooDeep when the reply would nest too deeply.
+	CreateComment(context.Context, *Comment) error
+	CommentByID(context.Context, uint) (*Comment, error)
+
+	// Comments returns comments oldest first.
+	Comments(context.Context, CommentFilter) ([]*Comment, error)
+
+	// DeleteComment removes the comment, or keeps it as a placeholder when
+	// it still has replies.
+	DeleteComment(context.Context, *Comment) error
+}
@@ -0,0 +1,191 @@
+package conduit
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around each change.
+	diffContext = 3
+
+	// maxDiffCells bounds the LCS table, which is quadratic in the number
+	// of changed lines, so huge revisions cannot exhaust memory.
+	maxDiffCells = 2_000_000
+)
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns a unified diff turning a into b, labelled with the
+// from and to names. Equal inputs give an empty diff. It fails with
+// ErrDiffTooLarge when too many lines changed to diff them.
+func UnifiedDiff(from, to, a, b string) (string, error) {
+	ops, err := diffLines(splitLines(a), splitLines(b))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, h := range diffHunks(ops) {
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", from, to)
+		}
+		sb.WriteString(h)
+	}
+
+	return sb.String(), nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes the line edit script through the longest common
+// subsequence. Unchanged leading and trailing lines are set aside first so
+// only the changed middle counts against maxDiffCells.
+func diffLines(a, b []string) ([]diffOp, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	tail := a[len(a)-suffix:]
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return nil, ErrDiffTooLarge
+	}
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+
+	for _, line := range tail {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops, nil
+}
+
+// diffHunks groups the edit script into hunks with surrounding context.
+func diffHunks(ops []diffOp) []string {
+	hunks := make([]string, 0)
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// extend the hunk while changes are close enough to share context
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			} else if k-last > 2*diffContext {
+				break
+			}
+		}
+
+		lo, hi := first-diffContext, last+diffContext+1
+		if lo < start {
+			lo = start
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+
+		aStart, bStart := 1, 1
+		for _, op := range ops[:lo] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+
+		var body strings.Builder
+		aLen, bLen := 0, 0
+		for _, op := range ops[lo:hi] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+			body.WriteByte(op.kind)
+			body.WriteString(op.line)
+			body.WriteByte('\n')
+		}
+
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+
+		hunks = append(hunks, fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)+body.String())
+		start = hi
+	}
+
+	return hunks
+}
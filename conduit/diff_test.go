@@ -0,0 +1,97 @@
+package conduit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines "1" to "n", each ending in a newline, with the
+// lines in replace swapped for their values.
+func numbered(n int, replace map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = fmt.Sprint(i)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"both empty", "", "", ""},
+		{"identical", "one\ntwo\n", "one\ntwo\n", ""},
+		{"missing final newline is not a change", "one\ntwo", "one\ntwo\n", ""},
+		{
+			"from empty", "", "one\ntwo\n",
+			"--- v1\n+++ v2\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			"to empty", "one\ntwo\n", "",
+			"--- v1\n+++ v2\n@@ -1,2 +0,0 @@\n-one\n-two\n",
+		},
+		{
+			"change keeps three lines of context", numbered(10, nil), numbered(10, map[int]string{5: "five"}),
+			"--- v1\n+++ v2\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"insertion at the start", "b\nc\n", "a\nb\nc\n",
+			"--- v1\n+++ v2\n@@ -1,2 +1,3 @@\n+a\n b\n c\n",
+		},
+		{
+			"close changes share a hunk", numbered(12, nil), numbered(12, map[int]string{3: "three", 8: "eight"}),
+			"--- v1\n+++ v2\n@@ -1,11 +1,11 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n 11\n",
+		},
+		{
+			"distant changes get their own hunks", numbered(20, nil), numbered(20, map[int]string{2: "two", 18: "eighteen"}),
+			"--- v1\n+++ v2\n@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnifiedDiff("v1", "v2", tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("UnifiedDiff: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffTooLarge(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 1500; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+
+	if _, err := UnifiedDiff("v1", "v2", a.String(), b.String()); !errors.Is(err, ErrDiffTooLarge) {
+		t.Errorf("rewriting 1500 lines: err = %v, want ErrDiffTooLarge", err)
+	}
+}
+
+func TestUnifiedDiffTrimsUnchangedEnds(t *testing.T) {
+	// far more lines than maxDiffCells allows to compare, but only one changed
+	n := 50000
+	got, err := UnifiedDiff("v1", "v2", numbered(n, nil), numbered(n, map[int]string{n / 2: "middle"}))
+	if err != nil {
+		t.Fatalf("UnifiedDiff: %v", err)
+	}
+
+	want := fmt.Sprintf("@@ -%d,7 +%d,7 @@\n", n/2-3, n/2-3)
+	if !strings.Contains(got, want) || strings.Count(got, "@@ -") != 1 {
+		t.Errorf("UnifiedDiff = %q, want a single hunk %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+// How often a user gets the digest of new articles from the authors and tags
+// they follow.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestPeriod is the time between two digests of frequency, zero when they
+// are off.
+func DigestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+type DigestPreference struct {
+	UserID     uint       `json:"-" db:"user_id"`
+	Frequency  string     `json:"frequency"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty" db:"last_sent_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+
+	// User is only loaded for claimed digests.
+	User *User `json:"-" db:"-"`
+}
+
+// Since is where the digest sent at now starts: the previous digest, or one
+// period back for the first one.
+func (p *DigestPreference) Since(now time.Time) time.Time {
+	if p.LastSentAt != nil {
+		return *p.LastSentAt
+	}
+
+	return now.Add(-DigestPeriod(p.Frequency))
+}
+
+type DigestService interface {
+	// DigestPreference returns the user's preference, off when they never
+	// opted in.
+	DigestPreference(context.Context, *User) (*DigestPreference, error)
+	SetDigestFrequency(ctx context.Context, user *User, frequency string) (*DigestPreference, error)
+
+	// ClaimDueDigests returns up to limit preferences whose digest is due at
+	// now, with their user, and records now as their last digest. LastSentAt
+	// holds the previous one, so each digest is sent at most once.
+	ClaimDueDigests(ctx context.Context, now time.Time, limit int) ([]*DigestPreference, error)
+}
+
+// Email is a message with a plain-text and an HTML body.
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+
+	// Headers are added to the standard ones, e.g. List-Unsubscribe.
+	Headers map[string]string
+}
+
+type Mailer interface {
+	Send(context.Context, *Email) error
+}
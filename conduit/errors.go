@@ -3,9 +3,18 @@ package conduit
 import "errors"
 
 var (
-	ErrDuplicateEmail    = errors.New("duplicate email")
-	ErrDuplicateUsername = errors.New("duplicate username")
-	ErrNotFound          = errors.New("record not found")
-	ErrUnAuthorized      = errors.New("unauthorized")
-	ErrInternal          = errors.New("internal error")
+	ErrDuplicateEmail       = errors.New("duplicate email")
+	ErrDuplicateUsername    = errors.New("duplicate username")
+	ErrDuplicateSlug        = errors.New("duplicate slug")
+	ErrDuplicateTag         = errors.New("duplicate tag")
+	ErrTagInUse             = errors.New("tag in use")
+	ErrInvalidArticleStatus = errors.New("invalid article status")
+	ErrInvalidPublishAt     = errors.New("publish time must be in the future")
+	ErrArticleInSeries      = errors.New("article already in another series")
+	ErrDuplicateCoAuthor    = errors.New("duplicate co-author")
+	ErrCommentTooDeep       = errors.New("comment nested too deeply")
+	ErrDiffTooLarge         = errors.New("revisions too large to diff")
+	ErrNotFound             = errors.New("record not found")
+	ErrUnAuthorized         = errors.New("unauthorized")
+	ErrInternal             = errors.New("internal error")
 )
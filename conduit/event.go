@@ -0,0 +1,55 @@
+package conduit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// EventNotification carries a new Notification.
+	EventNotification = "notification"
+	// EventComment carries a new comment on an article the user owns.
+	EventComment = "comment"
+	// EventArticle carries an article just published by someone the user
+	// follows.
+	EventArticle = "article"
+)
+
+// Event is something pushed to a user's live stream. IDs only grow, so a
+// client can resume from the last one it saw.
+type Event struct {
+	ID        uint64          `json:"id"`
+	UserID    uint            `json:"-" db:"user_id"`
+	Kind      string          `json:"kind"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// EventSink receives the events announced to Listen.
+type EventSink interface {
+	// Wants reports whether the user's events are needed here. Events of
+	// other users are not even loaded.
+	Wants(userID uint) bool
+	Publish(*Event)
+
+	// Missed is called whenever events may have been missed, such as
+	// while the connection announcing them was re-established.
+	Missed()
+}
+
+// EventService stores events as the other services write and delivers them
+// to every server instance.
+type EventService interface {
+	// EventsSince returns up to limit of the user's events after id, oldest
+	// first.
+	EventsSince(ctx context.Context, user *User, id uint64, limit int) ([]*Event, error)
+
+	// Listen hands sink each event stored from now on, by this or any other
+	// instance, until ctx is done.
+	Listen(ctx context.Context, sink EventSink) error
+
+	// ListenComments is like Listen for the comments written and deleted on
+	// every article.
+	ListenComments(ctx context.Context, fn func(*CommentEvent)) error
+}
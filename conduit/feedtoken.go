@@ -0,0 +1,27 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+// FeedToken lets a feed reader fetch a user's personal timeline, which needs
+// the user but cannot send their JWT. It grants nothing else.
+type FeedToken struct {
+	// Token is only known when it is generated; the service keeps a hash.
+	Token     string    `json:"token,omitempty" db:"-"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type FeedTokenService interface {
+	// FeedToken returns when the user's token was generated, or ErrNotFound
+	// when they have none.
+	FeedToken(context.Context, *User) (*FeedToken, error)
+
+	// SetFeedToken makes token the user's only feed token.
+	SetFeedToken(ctx context.Context, user *User, token string) (*FeedToken, error)
+	RevokeFeedToken(context.Context, *User) error
+
+	// UserByFeedToken returns the user token was generated for.
+	UserByFeedToken(ctx context.Context, token string) (*User, error)
+}
@@ -0,0 +1,23 @@
+package conduit
+
+import "regexp"
+
+// mentionPattern matches @username where the @ does not follow a word
+// character, so email addresses are not taken for mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_-]+)`)
+
+// ParseMentions returns the usernames mentioned in text, each once, in the
+// order they first appear.
+func ParseMentions(text string) []string {
+	usernames := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			usernames = append(usernames, m[1])
+		}
+	}
+
+	return usernames
+}
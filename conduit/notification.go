@@ -0,0 +1,53 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	NotificationMention  = "mention"
+	NotificationFollow   = "follow"
+	NotificationFavorite = "favorite"
+	NotificationComment  = "comment"
+)
+
+// Notification tells a user that someone else acted on them or their work.
+// Article and comment are set depending on the kind.
+type Notification struct {
+	ID            uint       `json:"id"`
+	Kind          string     `json:"kind"`
+	UserID        uint       `json:"-" db:"user_id"`
+	ActorID       uint       `json:"-" db:"actor_id"`
+	ActorUsername string     `json:"-" db:"actor_username"`
+	ActorImage    string     `json:"-" db:"actor_image"`
+	Actor         *Profile   `json:"actor" db:"-"`
+	ArticleID     *uint      `json:"-" db:"article_id"`
+	ArticleSlug   *string    `json:"articleSlug,omitempty" db:"article_slug"`
+	CommentID     *uint      `json:"commentId,omitempty" db:"comment_id"`
+	Read          bool       `json:"read" db:"-"`
+	ReadAt        *time.Time `json:"readAt,omitempty" db:"read_at"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+}
+
+func (n *Notification) SetActorProfile() {
+	n.Actor = &Profile{Username: n.ActorUsername, Image: n.ActorImage}
+	n.Read = n.ReadAt != nil
+}
+
+type NotificationFilter struct {
+	UnreadOnly bool
+
+	Limit  int
+	Offset int
+}
+
+// NotificationService keeps each user's inbox. Notifications are written by
+// the other services as users act; nobody is notified of their own actions
+// or of the actions of users they blocked.
+type NotificationService interface {
+	Notifications(ctx context.Context, user *User, filter NotificationFilter) ([]*Notification, error)
+	UnreadNotificationCount(ctx context.Context, user *User) (int, error)
+	MarkNotificationRead(ctx context.Context, user *User, id uint) error
+	MarkAllNotificationsRead(ctx context.Context, user *User) error
+}
@@ -0,0 +1,74 @@
+package conduit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Aggregates domain events are recorded against. Events of one aggregate are
+// published in the order they were recorded.
+const (
+	AggregateArticle = "article"
+	AggregateUser    = "user"
+	AggregateComment = "comment"
+)
+
+// Kinds of domain event. Comment events reuse CommentCreated and
+// CommentDeleted.
+const (
+	ArticleCreated     = "article.created"
+	ArticleUpdated     = "article.updated"
+	ArticlePublished   = "article.published"
+	ArticleDeleted     = "article.deleted"
+	ArticleFavorited   = "article.favorited"
+	ArticleUnfavorited = "article.unfavorited"
+	UserCreated        = "user.created"
+	UserUpdated        = "user.updated"
+	UserFollowed       = "user.followed"
+	UserUnfollowed     = "user.unfollowed"
+)
+
+// DomainEvent records a change made by one of the services. It is written to
+// the outbox in the transaction that made the change, so it exists if and
+// only if the change was committed.
+type DomainEvent struct {
+	ID            uint64          `json:"id"`
+	AggregateType string          `json:"aggregateType" db:"aggregate_type"`
+	AggregateID   uint            `json:"aggregateId" db:"aggregate_id"`
+	Kind          string          `json:"kind"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	PublishedAt   *time.Time      `json:"publishedAt,omitempty" db:"published_at"`
+}
+
+// EventPublisher hands domain events to whatever reacts to them. Publish may
+// be called more than once with the same event, so it should be idempotent
+// on the event ID.
+type EventPublisher interface {
+	Publish(context.Context, *DomainEvent) error
+}
+
+// Aggregate names the aggregate the event was recorded against, such as
+// article/12.
+func (e *DomainEvent) Aggregate() string {
+	return fmt.Sprintf("%s/%d", e.AggregateType, e.AggregateID)
+}
+
+// PendingDomainEvents returns up to limit unpublished events with an ID
+// above afterID, oldest first, leaving out the events of the skipped
+// aggregates.
+type PendingDomainEvents func(afterID uint64, skip []string, limit int) ([]*DomainEvent, error)
+
+type OutboxService interface {
+	// RelayDomainEvents calls relay with the unpublished events and marks
+	// those whose IDs it returns as published. Only one relay runs at a time
+	// across instances: while another holds the outbox, it returns 0 without
+	// calling relay. It returns how many events were marked.
+	RelayDomainEvents(ctx context.Context, relay func(PendingDomainEvents) ([]uint64, error)) (int, error)
+
+	// DeletePublishedDomainEvents deletes the events published before the
+	// given time and returns how many were deleted.
+	DeletePublishedDomainEvents(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,76 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ReactionLike       = "like"
+	ReactionInsightful = "insightful"
+	ReactionFunny      = "funny"
+	ReactionConfused   = "confused"
+)
+
+// ReactionKinds lists every reaction a user may leave, in display order.
+var ReactionKinds = []string{ReactionLike, ReactionInsightful, ReactionFunny, ReactionConfused}
+
+func IsReactionKind(kind string) bool {
+	for _, k := range ReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReactionTarget names what a reaction is left on: an article, or one of its
+// comments when CommentID is set.
+type ReactionTarget struct {
+	ArticleID uint
+	CommentID uint
+}
+
+// Reactions is the reactions block of an article or comment: how many of each
+// kind it received and which ones the viewer left.
+type Reactions struct {
+	Counts map[string]int `json:"counts"`
+	Mine   []string       `json:"mine"`
+}
+
+func NewReactions() *Reactions {
+	counts := make(map[string]int, len(ReactionKinds))
+	for _, k := range ReactionKinds {
+		counts[k] = 0
+	}
+
+	return &Reactions{Counts: counts, Mine: []string{}}
+}
+
+// Reaction is one user's reaction, as listed to show who reacted.
+type Reaction struct {
+	Kind      string    `json:"kind"`
+	UserID    uint      `json:"-" db:"user_id"`
+	Username  string    `json:"username"`
+	Image     string    `json:"image"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type ReactionFilter struct {
+	Kind *string
+
+	Limit  int
+	Offset int
+}
+
+type ReactionService interface {
+	// AddReaction records the reaction and returns the target's updated
+	// counts. Reacting twice with the same kind is not an error.
+	AddReaction(ctx context.Context, target ReactionTarget, user *User, kind string) (*Reactions, error)
+	RemoveReaction(ctx context.Context, target ReactionTarget, user *User, kind string) (*Reactions, error)
+	Reactions(ctx context.Context, target ReactionTarget, filter ReactionFilter) ([]*Reaction, error)
+
+	// ViewerReactions returns the kinds user left on each of targets.
+	ViewerReactions(ctx context.Context, user *User, targets ...ReactionTarget) (map[ReactionTarget][]string, error)
+}
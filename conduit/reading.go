@@ -0,0 +1,102 @@
+package conduit
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	wordsPerMinute = 200
+
+	// summaryLength is the length generated descriptions aim for
+	summaryLength = 160
+
+	// minSummaryParagraph is the length below which a paragraph without
+	// closing punctuation is taken for a heading and left out of summaries
+	minSummaryParagraph = 40
+)
+
+// SetBodyStats stores the word count and reading time of the article body,
+// given as plain text with any markup already stripped.
+func (a *Article) SetBodyStats(plainBody string) {
+	a.WordCount = len(strings.Fields(plainBody))
+	a.ReadingTime = ReadingTime(a.WordCount)
+}
+
+// ReadingTime returns the minutes needed to read words, rounded up.
+func ReadingTime(words int) int {
+	if words == 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// Summarize builds a description from the first sentences of plain text whose
+// paragraphs are separated by blank lines.
+func Summarize(plain string) string {
+	var sb strings.Builder
+
+	for _, p := range strings.Split(plain, "\n\n") {
+		p = strings.Join(strings.Fields(p), " ")
+		if p == "" || (len(p) < minSummaryParagraph && !endsSentence(p)) {
+			continue
+		}
+
+		for _, sentence := range splitSentences(p) {
+			if sb.Len() > 0 {
+				if sb.Len()+1+len(sentence) > summaryLength {
+					return sb.String()
+				}
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(sentence)
+
+			if sb.Len() >= summaryLength {
+				return truncateWords(sb.String(), summaryLength)
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+func splitSentences(p string) []string {
+	sentences := make([]string, 0)
+	start := 0
+	runes := []rune(p)
+
+	for i, r := range runes {
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			sentences = append(sentences, strings.TrimSpace(string(runes[start:i+1])))
+			start = i + 1
+		}
+	}
+
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+
+	return sentences
+}
+
+func endsSentence(s string) bool {
+	return strings.HasSuffix(s, ".") || strings.HasSuffix(s, "!") || strings.HasSuffix(s, "?")
+}
+
+func truncateWords(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	cut := strings.LastIndex(s[:n], " ")
+	if cut <= 0 {
+		// no space to break at: cut mid-word, but never mid-rune
+		cut = n
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+	}
+
+	return strings.TrimRight(s[:cut], " ,;:") + "…"
+}
@@ -0,0 +1,40 @@
+package conduit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ArticleRevision is an immutable snapshot of an article taken on every edit.
+type ArticleRevision struct {
+	ID             uint      `json:"-"`
+	ArticleID      uint      `json:"-" db:"article_id"`
+	Number         int       `json:"revision" db:"revision"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	Body           string    `json:"body"`
+	Tags           []string  `json:"tagList"`
+	EditorID       *uint     `json:"-" db:"editor_id"`
+	EditorUsername string    `json:"editor" db:"editor_username"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// Text renders the revision as the plain text that revision diffs compare.
+func (r *ArticleRevision) Text() string {
+	return fmt.Sprintf("Title: %s\nDescription: %s\nTags: %s\n\n%s\n",
+		r.Title, r.Description, strings.Join(r.Tags, ", "), r.Body)
+}
+
+// Patch returns the article patch that restores the article to this revision.
+func (r *ArticleRevision) Patch() ArticlePatch {
+	tags := make([]string, len(r.Tags))
+	copy(tags, r.Tags)
+
+	return ArticlePatch{
+		Title:       &r.Title,
+		Description: &r.Description,
+		Body:        &r.Body,
+		Tags:        tags,
+	}
+}
@@ -0,0 +1,39 @@
+package conduit
+
+import "context"
+
+type SearchQuery struct {
+	Text           string
+	Tag            *string
+	AuthorUsername *string
+
+	Limit  int
+	Offset int
+}
+
+type SearchHit struct {
+	ArticleID uint    `json:"-" db:"article_id"`
+	Slug      string  `json:"slug"`
+	Score     float64 `json:"score"`
+	Highlight string  `json:"highlight"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type SearchResult struct {
+	Hits   []*SearchHit            `json:"hits"`
+	Total  int                     `json:"total"`
+	Facets map[string][]FacetCount `json:"facets"`
+}
+
+// SearchIndex is implemented by the search backends. Index and Delete are
+// called whenever an article is written so that the backend stays in sync
+// with the articles table.
+type SearchIndex interface {
+	Index(context.Context, *Article) error
+	Delete(context.Context, *Article) error
+	Query(context.Context, SearchQuery) (*SearchResult, error)
+}
@@ -0,0 +1,89 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+// Series is an ordered collection of articles, such as a multi-part tutorial.
+type Series struct {
+	ID           uint           `json:"-"`
+	Title        string         `json:"title"`
+	Slug         string         `json:"slug"`
+	Description  string         `json:"description"`
+	OwnerID      uint           `json:"-" db:"owner_id"`
+	Owner        *User          `json:"-"`
+	OwnerProfile *Profile       `json:"owner"`
+	Articles     []*SeriesEntry `json:"articles"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type SeriesEntry struct {
+	ArticleID uint   `json:"-" db:"article_id"`
+	Position  int    `json:"position"`
+	Slug      string `json:"slug"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+}
+
+// ArticleSeries is the series block of an article: where the article sits in
+// its series and links to its published neighbours.
+type ArticleSeries struct {
+	Slug     string       `json:"slug"`
+	Title    string       `json:"title"`
+	Position int          `json:"position"`
+	Total    int          `json:"total"`
+	Previous *SeriesEntry `json:"previous"`
+	Next     *SeriesEntry `json:"next"`
+}
+
+func (s *Series) SetOwnerProfile(currentUser *User) {
+	s.OwnerProfile = &Profile{
+		Username:  s.Owner.Username,
+		Bio:       s.Owner.Bio,
+		Image:     s.Owner.Image,
+		Following: currentUser.IsFollowing(s.Owner),
+	}
+}
+
+// PublishedArticles drops the entries other users may not see yet.
+func (s *Series) PublishedArticles() []*SeriesEntry {
+	published := make([]*SeriesEntry, 0, len(s.Articles))
+	for _, e := range s.Articles {
+		if e.Status == ArticleStatusPublished {
+			published = append(published, e)
+		}
+	}
+	return published
+}
+
+type SeriesFilter struct {
+	ID            *uint
+	Slug          *string
+	OwnerUsername *string
+
+	Limit  int
+	Offset int
+}
+
+type SeriesPatch struct {
+	Title       *string
+	Description *string
+}
+
+type SeriesService interface {
+	CreateSeries(context.Context, *Series) error
+
+	SeriesBySlug(context.Context, string) (*Series, error)
+
+	Series(context.Context, SeriesFilter) ([]*Series, error)
+
+	UpdateSeries(context.Context, *Series, SeriesPatch) error
+
+	DeleteSeries(context.Context, *Series) error
+
+	// SetSeriesArticles replaces the articles of the series with articleIDs,
+	// in that order, in a single transaction.
+	SetSeriesArticles(ctx context.Context, series *Series, articleIDs []uint) error
+}
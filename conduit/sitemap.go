@@ -0,0 +1,28 @@
+package conduit
+
+import (
+	"context"
+	"time"
+)
+
+// Kinds of page listed in the sitemap.
+const (
+	SitemapArticle = "article"
+	SitemapProfile = "profile"
+)
+
+// SitemapEntry is a public page: a published article, by slug, or a
+// profile, by username.
+type SitemapEntry struct {
+	Kind      string
+	Key       string
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type SitemapService interface {
+	SitemapEntryCount(context.Context) (int, error)
+
+	// SitemapEntries returns the entries in a stable order, articles
+	// first, so that the sitemap can be split into pages.
+	SitemapEntries(ctx context.Context, limit, offset int) ([]*SitemapEntry, error)
+}
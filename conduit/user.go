@@ -17,6 +17,7 @@ type User struct {
 	Following    []*User   `json:"-"`
 	Followers    []*User   `json:"-"`
 	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         string    `json:"-"`
 	CreatedAt    time.Time `json:"-" db:"created_at"`
 	UpdatedAt    time.Time `json:"-" db:"updated_at"`
 }
@@ -37,6 +38,12 @@ func (me *User) IsFollowing(user *User) bool {
 	return false
 }
 
+const RoleModerator = "moderator"
+
+func (u *User) IsModerator() bool {
+	return u.Role == RoleModerator
+}
+
 var AnonymousUser User
 
 type UserFilter struct {
@@ -85,5 +92,16 @@ type UserService interface {
 
 	UserByEmail(ctx context.Context, email string) (*User, error)
 
+	UserByUsername(ctx context.Context, username string) (*User, error)
+
 	UpdateUser(context.Context, *User, UserPatch) error
+
+	FollowUser(ctx context.Context, follower, user *User) error
+
+	UnfollowUser(ctx context.Context, follower, user *User) error
+
+	// BlockUser stops user from notifying blocker.
+	BlockUser(ctx context.Context, blocker, user *User) error
+
+	UnblockUser(ctx context.Context, blocker, user *User) error
 }
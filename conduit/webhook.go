@@ -0,0 +1,134 @@
+package conduit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Events a webhook can subscribe to.
+const (
+	WebhookArticlePublished = "article.published"
+	WebhookArticleUpdated   = "article.updated"
+	WebhookCommentCreated   = "comment.created"
+	WebhookUserFollowed     = "user.followed"
+)
+
+var WebhookEvents = []string{WebhookArticlePublished, WebhookArticleUpdated, WebhookCommentCreated, WebhookUserFollowed}
+
+// A user webhook receives the events about its owner: their articles, the
+// comments on them and their new followers. A site webhook, managed by
+// moderators, receives the events of every user.
+const (
+	WebhookScopeUser = "user"
+	WebhookScopeSite = "site"
+)
+
+type Webhook struct {
+	ID      uint   `json:"id"`
+	OwnerID uint   `json:"-" db:"owner_id"`
+	Scope   string `json:"scope"`
+	URL     string `json:"url"`
+
+	// Secret signs the deliveries. It is only shown when the webhook is
+	// created.
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants event.
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+
+	// DeliveryDead is the dead-letter state of a delivery that failed every
+	// attempt. Only a manual redelivery sends it again.
+	DeliveryDead = "dead"
+)
+
+type WebhookDelivery struct {
+	ID             uint64          `json:"id"`
+	WebhookID      uint            `json:"-" db:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty" db:"next_attempt_at"`
+	LastStatusCode *int            `json:"lastStatusCode,omitempty" db:"last_status_code"`
+	LastError      *string         `json:"lastError,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty" db:"delivered_at"`
+
+	// Webhook is only loaded for deliveries claimed to be sent.
+	Webhook *Webhook `json:"-" db:"-"`
+}
+
+// DeliveryAttempt is the outcome of sending a delivery once. StatusCode is
+// zero when no response came back.
+type DeliveryAttempt struct {
+	StatusCode int
+	Err        error
+}
+
+func (a DeliveryAttempt) Succeeded() bool {
+	return a.Err == nil && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+type WebhookFilter struct {
+	ID      *uint
+	OwnerID *uint
+	Scope   *string
+
+	Limit  int
+	Offset int
+}
+
+type WebhookPatch struct {
+	URL    *string
+	Events []string
+	Active *bool
+}
+
+type WebhookDeliveryFilter struct {
+	ID     *uint64
+	Status *string
+
+	Limit  int
+	Offset int
+}
+
+type WebhookService interface {
+	CreateWebhook(context.Context, *Webhook) error
+	Webhooks(context.Context, WebhookFilter) ([]*Webhook, error)
+	UpdateWebhook(context.Context, *Webhook, WebhookPatch) error
+	DeleteWebhook(context.Context, *Webhook) error
+
+	// WebhookDeliveries returns the webhook's delivery log, newest first.
+	WebhookDeliveries(context.Context, *Webhook, WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+
+	// Redeliver queues a new delivery of the same payload, to be sent right
+	// away.
+	Redeliver(context.Context, *WebhookDelivery) (*WebhookDelivery, error)
+
+	// ClaimDueDeliveries returns up to limit pending deliveries due at now,
+	// with their webhook, and holds them back from other callers for lease.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+
+	// RecordDeliveryAttempt stores the outcome of sending the delivery. A
+	// failed attempt is retried at retryAt, or the delivery is dead when
+	// retryAt is nil.
+	RecordDeliveryAttempt(ctx context.Context, delivery *WebhookDelivery, attempt DeliveryAttempt, retryAt *time.Time) error
+}
@@ -0,0 +1,242 @@
+// Package digest emails users the new articles of the authors and tags they
+// follow.
+package digest
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// Number of articles listed per section.
+const (
+	authorArticles = 20
+	tagArticles    = 5
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Sender claims the digests that are due and mails them. Digests with
+// nothing new in them are skipped.
+type Sender struct {
+	Articles conduit.ArticleService
+	Digests  conduit.DigestService
+	Mailer   conduit.Mailer
+
+	// BaseURL is where the site is served, without a trailing slash. Links
+	// in the email are made absolute with it.
+	BaseURL   string
+	BatchSize int
+
+	// Now tells which digests are due and how far back their articles go.
+	Now func() time.Time
+
+	secret []byte
+	html   *htmltemplate.Template
+	text   *texttemplate.Template
+}
+
+func NewSender(articles conduit.ArticleService, digests conduit.DigestService, mailer conduit.Mailer, baseURL string, secret []byte) *Sender {
+	s := &Sender{
+		Articles:  articles,
+		Digests:   digests,
+		Mailer:    mailer,
+		BaseURL:   strings.TrimSuffix(baseURL, "/"),
+		BatchSize: 50,
+		Now:       time.Now,
+		secret:    secret,
+	}
+
+	funcs := map[string]interface{}{"articleURL": s.articleURL}
+	s.html = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).ParseFS(templates, "templates/digest.html"))
+	s.text = texttemplate.Must(texttemplate.New("digest.txt").Funcs(funcs).ParseFS(templates, "templates/digest.txt"))
+
+	return s
+}
+
+// Run sends the due digests every interval until ctx is done.
+func (s *Sender) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.SendDue(ctx)
+			if err != nil {
+				log.Printf("cannot send digests: %v", err)
+			}
+
+			if err != nil || n < s.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue claims one batch of due digests and mails them. It returns how
+// many were claimed. A digest that cannot be mailed is logged and skipped
+// until the next period.
+func (s *Sender) SendDue(ctx context.Context) (int, error) {
+	now := s.Now()
+
+	prefs, err := s.Digests.ClaimDueDigests(ctx, now, s.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range prefs {
+		email, err := s.Compose(ctx, p, now)
+		if err != nil {
+			log.Printf("cannot compose digest of %s: %v", p.User.Username, err)
+			continue
+		}
+
+		if email == nil {
+			continue
+		}
+
+		if err := s.Mailer.Send(ctx, email); err != nil {
+			log.Printf("cannot mail digest to %s: %v", p.User.Username, err)
+		}
+	}
+
+	return len(prefs), nil
+}
+
+type digest struct {
+	User           *conduit.User
+	Frequency      string
+	FromAuthors    []*conduit.Article
+	FromTags       []*conduit.Article
+	UnsubscribeURL string
+}
+
+// Compose renders the digest of pref sent at now, or returns nil when no
+// article was published since the previous one.
+func (s *Sender) Compose(ctx context.Context, pref *conduit.DigestPreference, now time.Time) (*conduit.Email, error) {
+	since := pref.Since(now)
+
+	fromAuthors, err := s.Articles.ArticleFeed(ctx, pref.User, conduit.ArticleFilter{
+		FeedSource:     conduit.FeedSourceAuthors,
+		PublishedSince: &since,
+		Limit:          authorArticles,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch extra to make up for the articles already listed by author
+	fromTags, err := s.Articles.ArticleFeed(ctx, pref.User, conduit.ArticleFilter{
+		FeedSource:     conduit.FeedSourceTags,
+		PublishedSince: &since,
+		Sort:           conduit.SortFavoritesDesc,
+		Limit:          tagArticles + len(fromAuthors),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(map[uint]bool, len(fromAuthors))
+	for _, a := range fromAuthors {
+		listed[a.ID] = true
+	}
+
+	top := make([]*conduit.Article, 0, tagArticles)
+	for _, a := range fromTags {
+		if !listed[a.ID] && len(top) < tagArticles {
+			top = append(top, a)
+		}
+	}
+
+	if len(fromAuthors) == 0 && len(top) == 0 {
+		return nil, nil
+	}
+
+	unsubscribe := s.BaseURL + "/api/v1/digest/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(s.secret, pref.UserID))
+
+	data := digest{
+		User:           pref.User,
+		Frequency:      pref.Frequency,
+		FromAuthors:    fromAuthors,
+		FromTags:       top,
+		UnsubscribeURL: unsubscribe,
+	}
+
+	var html, text bytes.Buffer
+
+	if err := s.html.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	if err := s.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	return &conduit.Email{
+		To:      pref.User.Email,
+		Subject: fmt.Sprintf("Your %s digest: %d new articles", pref.Frequency, len(fromAuthors)+len(top)),
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
+
+func (s *Sender) articleURL(a *conduit.Article) string {
+	return s.BaseURL + "/articles/" + url.PathEscape(a.Slug)
+}
+
+// UnsubscribeToken returns the token of the user's unsubscribe link, so that
+// it works without signing in.
+func UnsubscribeToken(secret []byte, userID uint) string {
+	id := strconv.FormatUint(uint64(userID), 10)
+	return id + "." + unsubscribeSignature(secret, id)
+}
+
+// ParseUnsubscribeToken returns the user a token was made for, or false when
+// it was not signed with secret.
+func ParseUnsubscribeToken(secret []byte, token string) (uint, bool) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(unsubscribeSignature(secret, parts[0]))) {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+func unsubscribeSignature(secret []byte, id string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("digest-unsubscribe:" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,209 @@
+package digest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/mail"
+)
+
+// fakeArticleService answers ArticleFeed from fixed lists per feed source.
+type fakeArticleService struct {
+	conduit.ArticleService
+
+	feeds map[string][]*conduit.Article
+	since []time.Time
+}
+
+func (fs *fakeArticleService) ArticleFeed(ctx context.Context, user *conduit.User, filter conduit.ArticleFilter) ([]*conduit.Article, error) {
+	fs.since = append(fs.since, *filter.PublishedSince)
+
+	articles := fs.feeds[filter.FeedSource]
+	if filter.Limit > 0 && len(articles) > filter.Limit {
+		articles = articles[:filter.Limit]
+	}
+
+	return articles, nil
+}
+
+// fakeDigestService hands out the due preferences once.
+type fakeDigestService struct {
+	conduit.DigestService
+
+	due []*conduit.DigestPreference
+}
+
+func (fs *fakeDigestService) ClaimDueDigests(ctx context.Context, now time.Time, limit int) ([]*conduit.DigestPreference, error) {
+	claimed := fs.due
+	fs.due = nil
+	return claimed, nil
+}
+
+var secret = []byte("secret")
+
+// the same article reaches the digest through both a followed author and a
+// followed tag
+var (
+	goGenerics = &conduit.Article{
+		ID: 1, Slug: "go-generics", Title: "Go generics", Description: "About Go generics",
+		Author: &conduit.User{Username: "rob"},
+	}
+	sqlTips = &conduit.Article{
+		ID: 2, Slug: "sql-tips", Title: "SQL tips", Description: "About SQL tips",
+		Author: &conduit.User{Username: "ken"},
+	}
+)
+
+func alice(frequency string, lastSent *time.Time) *conduit.DigestPreference {
+	return &conduit.DigestPreference{
+		UserID:     7,
+		Frequency:  frequency,
+		LastSentAt: lastSent,
+		User:       &conduit.User{ID: 7, Username: "alice", Email: "alice@example.com"},
+	}
+}
+
+func TestSendDueMailsDigests(t *testing.T) {
+	dir := t.TempDir()
+
+	mailer, err := mail.NewFileMailer(dir, "Conduit <digest@example.com>")
+	if err != nil {
+		t.Fatalf("NewFileMailer: %v", err)
+	}
+
+	articles := &fakeArticleService{feeds: map[string][]*conduit.Article{
+		conduit.FeedSourceAuthors: {goGenerics},
+		conduit.FeedSourceTags:    {goGenerics, sqlTips},
+	}}
+
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+	lastSent := now.Add(-24 * time.Hour)
+
+	digests := &fakeDigestService{due: []*conduit.DigestPreference{alice(conduit.DigestDaily, &lastSent)}}
+
+	s := NewSender(articles, digests, mailer, "https://conduit.example.com/", secret)
+	s.Now = func() time.Time { return now }
+
+	n, err := s.SendDue(context.Background())
+	if err != nil {
+		t.Fatalf("SendDue: %v", err)
+	}
+
+	if n != 1 {
+		t.Fatalf("SendDue claimed %d digests, want 1", n)
+	}
+
+	for _, since := range articles.since {
+		if !since.Equal(lastSent) {
+			t.Errorf("feed asked for articles since %v, want %v", since, lastSent)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("mailed %d emails (%v), want 1", len(files), err)
+	}
+
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(raw)
+
+	for _, want := range []string{
+		"To: alice@example.com\r\n",
+		"Subject: Your daily digest: 2 new articles\r\n",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n",
+		"https://conduit.example.com/articles/go-generics",
+		"https://conduit.example.com/articles/sql-tips",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("email does not contain %q:\n%s", want, msg)
+		}
+	}
+
+	if c := strings.Count(msg, "* Go generics by rob"); c != 1 {
+		t.Errorf("plain text lists the followed author's article %d times, want once", c)
+	}
+
+	token := unsubscribeTokenIn(t, msg)
+	if id, ok := ParseUnsubscribeToken(secret, token); !ok || id != 7 {
+		t.Errorf("unsubscribe token %q parses to %d, %v; want 7, true", token, id, ok)
+	}
+}
+
+func TestComposeSkipsEmptyDigest(t *testing.T) {
+	articles := &fakeArticleService{feeds: map[string][]*conduit.Article{}}
+	s := NewSender(articles, &fakeDigestService{}, nil, "https://conduit.example.com", secret)
+
+	now := time.Date(2021, 6, 7, 8, 0, 0, 0, time.UTC)
+
+	email, err := s.Compose(context.Background(), alice(conduit.DigestWeekly, nil), now)
+	if err != nil {
+		t.Fatalf("Compose: %v", err)
+	}
+
+	if email != nil {
+		t.Errorf("Compose = %+v, want no email", email)
+	}
+
+	if want := now.Add(-7 * 24 * time.Hour); len(articles.since) == 0 || !articles.since[0].Equal(want) {
+		t.Errorf("first weekly digest asked for articles since %v, want %v", articles.since, want)
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	token := UnsubscribeToken(secret, 42)
+
+	if id, ok := ParseUnsubscribeToken(secret, token); !ok || id != 42 {
+		t.Errorf("ParseUnsubscribeToken(%q) = %d, %v; want 42, true", token, id, ok)
+	}
+
+	if _, ok := ParseUnsubscribeToken([]byte("other"), token); ok {
+		t.Errorf("token %q accepted with the wrong secret", token)
+	}
+
+	forged := "43" + strings.TrimPrefix(token, "42")
+	if _, ok := ParseUnsubscribeToken(secret, forged); ok {
+		t.Errorf("token %q accepted for another user", forged)
+	}
+
+	for _, token := range []string{"", "42", "42.", "x." + strings.SplitN(token, ".", 2)[1]} {
+		if _, ok := ParseUnsubscribeToken(secret, token); ok {
+			t.Errorf("malformed token %q accepted", token)
+		}
+	}
+}
+
+// unsubscribeTokenIn returns the token of the List-Unsubscribe link in msg.
+func unsubscribeTokenIn(t *testing.T, msg string) string {
+	t.Helper()
+
+	for _, line := range strings.Split(msg, "\r\n") {
+		if !strings.HasPrefix(line, "List-Unsubscribe: ") {
+			continue
+		}
+
+		link := strings.Trim(strings.TrimPrefix(line, "List-Unsubscribe: "), "<>")
+
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("List-Unsubscribe link %q: %v", link, err)
+		}
+
+		if u.Path != "/api/v1/digest/unsubscribe" {
+			t.Errorf("List-Unsubscribe link %q, want the unsubscribe endpoint", link)
+		}
+
+		return u.Query().Get("token")
+	}
+
+	t.Fatalf("email has no List-Unsubscribe header:\n%s", msg)
+	return ""
+}
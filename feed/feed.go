@@ -0,0 +1,208 @@
+// Package feed writes Atom and RSS 2.0 feeds.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+type Feed struct {
+	ID       string
+	Title    string
+	Subtitle string
+
+	// Link is the page the feed is about and Self the feed itself.
+	Link    string
+	Self    string
+	Updated time.Time
+	Entries []*Entry
+}
+
+type Entry struct {
+	// ID is the entry's GUID. It must never change, whatever happens to the
+	// rest of the entry.
+	ID         string
+	Title      string
+	Link       string
+	Author     string
+	Categories []string
+	Summary    string
+
+	// Content is the HTML of the full entry. Summary is used when it is
+	// empty.
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+)
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Links    []atomLink   `xml:"link"`
+	Updated  string       `xml:"updated"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Author     atomAuthor     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func WriteAtom(w io.Writer, f *Feed) error {
+	out := atomFeed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+	}
+
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Link:      atomLink{Href: e.Link, Rel: "alternate", Type: "text/html"},
+			Author:    atomAuthor{e.Author},
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+		}
+
+		for _, c := range e.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{c})
+		}
+
+		if e.Summary != "" {
+			entry.Summary = &atomText{"text", e.Summary}
+		}
+
+		if e.Content != "" {
+			entry.Content = &atomText{"html", e.Content}
+		}
+
+		out.Entries = append(out.Entries, &entry)
+	}
+
+	return write(w, out)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Self          atomLink   `xml:"atom:link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	GUID        rssGUID  `xml:"guid"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Author      string   `xml:"dc:creator"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS writes an RSS 2.0 feed. RSS has no separate summary, so the
+// description of an item is its content when it has some.
+func WriteRSS(w io.Writer, f *Feed) error {
+	// RSS requires a channel description
+	description := f.Subtitle
+	if description == "" {
+		description = f.Title
+	}
+
+	out := rssFeed{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Self:          atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+			Description:   description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, e := range f.Entries {
+		description := e.Content
+		if description == "" {
+			description = e.Summary
+		}
+
+		out.Channel.Items = append(out.Channel.Items, &rssItem{
+			GUID:        rssGUID{false, e.ID},
+			Title:       e.Title,
+			Link:        e.Link,
+			Author:      e.Author,
+			Categories:  e.Categories,
+			Description: description,
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return write(w, out)
+}
+
+func write(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(v)
+}
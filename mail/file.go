@@ -0,0 +1,105 @@
+// Package mail sends email.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.Mailer = (*FileMailer)(nil)
+
+// FileMailer writes each email as an .eml file into a directory instead of
+// sending it, for tests and local development.
+type FileMailer struct {
+	dir  string
+	from string
+
+	mu sync.Mutex
+	n  int
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(_ context.Context, email *conduit.Email) error {
+	msg, err := Format(m.from, email)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.n++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().UTC().Format("20060102T150405"), m.n)
+	m.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(m.dir, name), msg, 0o644)
+}
+
+// Format renders email as a multipart/alternative MIME message.
+func Format(from string, email *conduit.Email) ([]byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	headers := map[string]string{
+		"From":         from,
+		"To":           email.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", email.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": "multipart/alternative; boundary=" + w.Boundary(),
+	}
+
+	for k, v := range email.Headers {
+		headers[k] = v
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var head bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&head, "%s: %s\r\n", k, headers[k])
+	}
+	head.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	}
+
+	for _, p := range parts {
+		pw, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {p.contentType}})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := pw.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
@@ -1,16 +1,46 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/mail"
+	"github.com/msksgm/go-realworld-msksgm-copy/memsearch"
+	"github.com/msksgm/go-realworld-msksgm-copy/outbox"
 	"github.com/msksgm/go-realworld-msksgm-copy/postgres"
 	"github.com/msksgm/go-realworld-msksgm-copy/server"
 )
 
+const (
+	// shutdownTimeout is how long open requests get to finish on shutdown.
+	shutdownTimeout = 10 * time.Second
+
+	// indexFlushInterval is how often changes to the embedded search index
+	// are written to disk.
+	indexFlushInterval = 5 * time.Second
+)
+
 type config struct {
-	port  string
-	dbURI string
+	port            string
+	dbURI           string
+	searchBackend   string
+	searchIndexPath string
+	commentMaxDepth int
+	outboxPublisher string
+	baseURL         string
+	mailDir         string
+	mailFrom        string
+	robotsTxtPath   string
 }
 
 func main() {
@@ -21,8 +51,129 @@ func main() {
 		log.Fatalf("cannot open database: %v", err)
 	}
 
-	srv := server.NewServer(db)
-	log.Fatal(srv.Run(cfg.port))
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := reindex(db, cfg); err != nil {
+			log.Fatalf("cannot rebuild search index: %v", err)
+		}
+		return
+	}
+
+	var (
+		searchIndex conduit.SearchIndex
+		index       *memsearch.Index
+	)
+
+	indexCtx, stopIndex := context.WithCancel(context.Background())
+	defer stopIndex()
+
+	if cfg.searchBackend == "embedded" {
+		index, err = memsearch.Open(cfg.searchIndexPath)
+		if err != nil {
+			log.Fatalf("cannot open search index: %v", err)
+		}
+		searchIndex = index
+
+		go index.Run(indexCtx, indexFlushInterval)
+		go rebuildOnHangup(indexCtx, db, index)
+	}
+
+	opts := server.Options{CommentMaxDepth: cfg.commentMaxDepth, BaseURL: cfg.baseURL}
+
+	if cfg.robotsTxtPath != "" {
+		robots, err := os.ReadFile(cfg.robotsTxtPath)
+		if err != nil {
+			log.Fatalf("cannot read robots.txt: %v", err)
+		}
+		opts.RobotsTxt = string(robots)
+	}
+
+	if cfg.mailDir != "" {
+		opts.Mailer, err = mail.NewFileMailer(cfg.mailDir, cfg.mailFrom)
+		if err != nil {
+			log.Fatalf("cannot open mail directory: %v", err)
+		}
+	}
+
+	if cfg.outboxPublisher == "log" {
+		opts.EventPublisher = outbox.NewLogPublisher(log.New(os.Stdout, "outbox: ", log.LstdFlags))
+	}
+
+	srv := server.NewServer(db, searchIndex, opts)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("cannot shut down cleanly: %v", err)
+		}
+	}()
+
+	if err := srv.Run(cfg.port); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+
+	<-stopped
+
+	if index != nil {
+		stopIndex()
+		if err := index.Flush(); err != nil {
+			log.Printf("cannot save search index: %v", err)
+		}
+	}
+}
+
+// reindex rebuilds the embedded search index file from the articles table.
+// The server must be stopped meanwhile: it keeps its own copy of the index
+// in memory and would write it back over the rebuilt file. To rebuild the
+// index of a running server, send it SIGHUP instead.
+func reindex(db *postgres.DB, cfg config) error {
+	idx, err := memsearch.Open(cfg.searchIndexPath)
+	if err != nil {
+		return err
+	}
+
+	return rebuildIndex(context.Background(), db, idx)
+}
+
+// rebuildOnHangup rebuilds idx in place each time the process gets SIGHUP,
+// until ctx is done.
+func rebuildOnHangup(ctx context.Context, db *postgres.DB, idx *memsearch.Index) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := rebuildIndex(ctx, db, idx); err != nil {
+				log.Printf("cannot rebuild search index: %v", err)
+			}
+		}
+	}
+}
+
+func rebuildIndex(ctx context.Context, db *postgres.DB, idx *memsearch.Index) error {
+	articles, err := postgres.NewArticleService(db).Articles(ctx, conduit.ArticleFilter{})
+	if err != nil {
+		return err
+	}
+
+	if err := idx.Rebuild(ctx, articles); err != nil {
+		return err
+	}
+
+	log.Printf("indexed %d articles", len(articles))
+	return nil
 }
 
 func envConfig() config {
@@ -38,5 +189,73 @@ func envConfig() config {
 		panic("POSTGRESQL_URL not provided")
 	}
 
-	return config{port: port, dbURI: dbURI}
+	searchBackend, ok := os.LookupEnv("SEARCH_BACKEND")
+
+	if !ok {
+		searchBackend = "postgres"
+	}
+
+	searchIndexPath, ok := os.LookupEnv("SEARCH_INDEX_PATH")
+
+	if !ok {
+		searchIndexPath = "search.idx"
+	}
+
+	commentMaxDepth := conduit.DefaultCommentMaxDepth
+
+	if v, ok := os.LookupEnv("COMMENT_MAX_DEPTH"); ok {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 1 {
+			panic("COMMENT_MAX_DEPTH must be a positive number")
+		}
+		commentMaxDepth = depth
+	}
+
+	// "none" leaves domain events in the outbox for another consumer
+	outboxPublisher, ok := os.LookupEnv("OUTBOX_PUBLISHER")
+
+	if !ok {
+		outboxPublisher = "log"
+	}
+
+	if outboxPublisher != "log" && outboxPublisher != "none" {
+		panic("OUTBOX_PUBLISHER must be log or none")
+	}
+
+	// the canonical public address of the site, which the links in emails,
+	// feeds and the sitemap are made from
+	baseURL, ok := os.LookupEnv("BASE_URL")
+
+	if !ok {
+		baseURL = "http://localhost:" + strings.TrimPrefix(port, ":")
+	}
+
+	if u, err := url.Parse(baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		panic("BASE_URL must be an absolute URL")
+	}
+
+	// digests are only sent when MAIL_DIR is set, as files into it
+	mailDir := os.Getenv("MAIL_DIR")
+
+	mailFrom, ok := os.LookupEnv("MAIL_FROM")
+
+	if !ok {
+		mailFrom = "Conduit <noreply@localhost>"
+	}
+
+	// served instead of the default robots.txt when set
+	robotsTxtPath := os.Getenv("ROBOTS_TXT_PATH")
+
+	return config{
+		port:            port,
+		dbURI:           dbURI,
+		searchBackend:   searchBackend,
+		searchIndexPath: searchIndexPath,
+		commentMaxDepth: commentMaxDepth,
+		outboxPublisher: outboxPublisher,
+		baseURL:         baseURL,
+		mailDir:         mailDir,
+		mailFrom:        mailFrom,
+		robotsTxtPath:   robotsTxtPath,
+	}
 }
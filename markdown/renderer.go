@@ -0,0 +1,80 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+var _ conduit.Renderer = (*Renderer)(nil)
+
+// Renderer converts CommonMark with GFM tables, strikethrough and autolinks
+// to HTML and sanitizes the result against an allow-list, so article bodies
+// can be embedded in a page as is.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+func NewRenderer() *Renderer {
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.Strikethrough,
+			extension.Linkify,
+		),
+	)
+
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("align").OnElements("th", "td")
+	policy.RequireNoFollowOnLinks(true)
+
+	return &Renderer{md: md, policy: policy}
+}
+
+func (r *Renderer) Render(body string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(body), &buf); err != nil {
+		return "", err
+	}
+
+	return r.policy.Sanitize(buf.String()), nil
+}
+
+func (r *Renderer) PlainText(body string) string {
+	src := []byte(body)
+	doc := r.md.Parser().Parse(text.NewReader(src))
+
+	var buf bytes.Buffer
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock && n.Kind() != ast.KindDocument {
+				buf.WriteString("\n\n")
+			}
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.CodeBlock, *ast.FencedCodeBlock, *ast.HTMLBlock, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			buf.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		case *ast.AutoLink:
+			buf.Write(n.Label(src))
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return buf.String()
+}
@@ -0,0 +1,446 @@
+package memsearch
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"html"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.SearchIndex = (*Index)(nil)
+
+// field weights mirror the default postgres ts_rank weights for A, B and C
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+	bodyWeight        = 0.2
+)
+
+// highlightWords is the number of words kept around the first match
+const highlightWords = 30
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+type document struct {
+	ID          uint
+	Slug        string
+	Title       string
+	Description string
+	Body        string
+	Author      string
+	Tags        []string
+	CreatedAt   time.Time
+}
+
+// Index is an embedded inverted index over articles. It lives in memory and,
+// when opened with a path, is written to disk by Flush so it survives
+// restarts without an external service. Changes are only marked dirty, as
+// encoding the whole index on every write would not scale; run Run or call
+// Flush before exiting to keep them.
+type Index struct {
+	mu       sync.RWMutex
+	path     string
+	dirty    bool
+	docs     map[uint]*document
+	postings map[string]map[uint]float64 // term -> article id -> weighted term frequency
+}
+
+// Open returns an index backed by the file at path, loading it if it exists.
+// An empty path gives a purely in-memory index.
+func Open(path string) (*Index, error) {
+	idx := &Index{
+		path:     path,
+		docs:     make(map[uint]*document),
+		postings: make(map[string]map[uint]float64),
+	}
+
+	if path == "" {
+		return idx, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return idx, nil
+		}
+		return nil, err
+	}
+
+	defer f.Close()
+
+	docs := make([]*document, 0)
+	if err := gob.NewDecoder(f).Decode(&docs); err != nil {
+		return nil, err
+	}
+
+	for _, d := range docs {
+		idx.add(d)
+	}
+
+	return idx, nil
+}
+
+func (idx *Index) Index(_ context.Context, article *conduit.Article) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(article.ID)
+	idx.add(newDocument(article))
+	idx.dirty = true
+
+	return nil
+}
+
+func (idx *Index) Delete(_ context.Context, article *conduit.Article) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(article.ID)
+	idx.dirty = true
+
+	return nil
+}
+
+// Rebuild replaces the whole index with the given articles and writes it
+// out right away.
+func (idx *Index) Rebuild(_ context.Context, articles []*conduit.Article) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = make(map[uint]*document)
+	idx.postings = make(map[string]map[uint]float64)
+
+	for _, a := range articles {
+		idx.add(newDocument(a))
+	}
+
+	return idx.save()
+}
+
+// Flush writes the index to its file if it changed since the last write.
+func (idx *Index) Flush() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.dirty {
+		return nil
+	}
+
+	return idx.save()
+}
+
+// Run flushes the index every interval until ctx is done.
+func (idx *Index) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := idx.Flush(); err != nil {
+				log.Printf("cannot save search index: %v", err)
+			}
+		}
+	}
+}
+
+func (idx *Index) Query(_ context.Context, q conduit.SearchQuery) (*conduit.SearchResult, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	result := &conduit.SearchResult{
+		Hits:   make([]*conduit.SearchHit, 0),
+		Facets: map[string][]conduit.FacetCount{"tag": {}, "author": {}},
+	}
+
+	terms := uniqueTerms(tokenize(q.Text))
+	if len(terms) == 0 {
+		return result, nil
+	}
+
+	scores := make(map[uint]float64)
+	for i, term := range terms {
+		postings := idx.postings[term]
+		idf := math.Log(1 + float64(len(idx.docs))/float64(len(postings)+1))
+
+		next := make(map[uint]float64)
+		for id, tf := range postings {
+			if _, ok := scores[id]; i > 0 && !ok {
+				continue
+			}
+			next[id] = scores[id] + tf*idf
+		}
+		scores = next
+	}
+
+	matches := make([]*document, 0, len(scores))
+	for id := range scores {
+		d := idx.docs[id]
+		if v := q.Tag; v != nil && !hasTag(d, *v) {
+			continue
+		}
+		if v := q.AuthorUsername; v != nil && d.Author != *v {
+			continue
+		}
+		matches = append(matches, d)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		si, sj := scores[matches[i].ID], scores[matches[j].ID]
+		if si != sj {
+			return si > sj
+		}
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
+
+	result.Total = len(matches)
+	result.Facets["tag"] = facetCounts(matches, func(d *document) []string { return d.Tags })
+	result.Facets["author"] = facetCounts(matches, func(d *document) []string { return []string{d.Author} })
+
+	for _, d := range paginate(matches, q.Limit, q.Offset) {
+		result.Hits = append(result.Hits, &conduit.SearchHit{
+			ArticleID: d.ID,
+			Slug:      d.Slug,
+			Score:     scores[d.ID],
+			Highlight: highlight(d.Body, terms),
+		})
+	}
+
+	return result, nil
+}
+
+func (idx *Index) add(d *document) {
+	idx.docs[d.ID] = d
+
+	for term, tf := range documentTerms(d) {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[uint]float64)
+		}
+		idx.postings[term][d.ID] = tf
+	}
+}
+
+func (idx *Index) remove(id uint) {
+	d, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range documentTerms(d) {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+
+	delete(idx.docs, id)
+}
+
+// save writes the documents to a temporary file and renames it over the
+// index file so a crash never leaves a half written index behind.
+func (idx *Index) save() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	docs := make([]*document, 0, len(idx.docs))
+	for _, d := range idx.docs {
+		docs = append(docs, d)
+	}
+
+	tmp := idx.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewEncoder(f).Encode(docs); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, idx.path); err != nil {
+		return err
+	}
+
+	idx.dirty = false
+
+	return nil
+}
+
+func newDocument(a *conduit.Article) *document {
+	d := &document{
+		ID:          a.ID,
+		Slug:        a.Slug,
+		Title:       a.Title,
+		Description: a.Description,
+		Body:        a.Body,
+		CreatedAt:   a.CreatedAt,
+	}
+
+	if a.Author != nil {
+		d.Author = a.Author.Username
+	}
+
+	for _, t := range a.Tags {
+		d.Tags = append(d.Tags, t.Name)
+	}
+
+	return d
+}
+
+func documentTerms(d *document) map[string]float64 {
+	terms := make(map[string]float64)
+
+	for _, f := range []struct {
+		text   string
+		weight float64
+	}{
+		{d.Title, titleWeight},
+		{d.Description, descriptionWeight},
+		{d.Body, bodyWeight},
+	} {
+		for _, term := range tokenize(f.text) {
+			terms[term] += f.weight
+		}
+	}
+
+	return terms
+}
+
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if !stopWords[w] {
+			terms = append(terms, w)
+		}
+	}
+
+	return terms
+}
+
+// uniqueTerms drops repeated terms, so a query term counts once however
+// often it is typed.
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+func hasTag(d *document, tag string) bool {
+	for _, t := range d.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func facetCounts(docs []*document, values func(*document) []string) []conduit.FacetCount {
+	counts := make(map[string]int)
+	for _, d := range docs {
+		for _, v := range values(d) {
+			counts[v]++
+		}
+	}
+
+	facets := make([]conduit.FacetCount, 0, len(counts))
+	for v, c := range counts {
+		facets = append(facets, conduit.FacetCount{Value: v, Count: c})
+	}
+
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+
+	return facets
+}
+
+func paginate(docs []*document, limit, offset int) []*document {
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset >= len(docs) {
+		return nil
+	}
+
+	docs = docs[offset:]
+	if limit > 0 && limit < len(docs) {
+		docs = docs[:limit]
+	}
+
+	return docs
+}
+
+// highlight returns a fragment of the body around the first matching term,
+// wrapping matches in <b> tags like ts_headline does.
+func highlight(body string, terms []string) string {
+	words := strings.Fields(body)
+	wanted := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		wanted[t] = true
+	}
+
+	first := -1
+	for i, w := range words {
+		// the body is raw article text: only the <b> tags are markup
+		words[i] = html.EscapeString(w)
+
+		for _, t := range tokenize(w) {
+			if wanted[t] {
+				words[i] = "<b>" + words[i] + "</b>"
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+
+	start := 0
+	if first > highlightWords/2 {
+		start = first - highlightWords/2
+	}
+
+	end := start + highlightWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	return strings.Join(words[start:end], " ")
+}
@@ -0,0 +1,223 @@
+package memsearch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var corpus = []*conduit.Article{
+	{
+		ID: 1, Slug: "go-generics", Title: "Go generics",
+		Description: "Type parameters in Go", Body: "Generics arrive in Go with type parameters.",
+		Author: &conduit.User{Username: "rob"}, Tags: []*conduit.Tag{{Name: "go"}, {Name: "Generics"}},
+		CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID: 2, Slug: "go-errors", Title: "Errors in Go",
+		Description: "Wrapping errors", Body: "Wrap errors with %w and unwrap them with errors.Is.",
+		Author: &conduit.User{Username: "rob"}, Tags: []*conduit.Tag{{Name: "go"}},
+		CreatedAt: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID: 3, Slug: "sql-tips", Title: "SQL tips",
+		Description: "Indexes and go-faster queries", Body: "Add an index before the query gets slow.",
+		Author: &conduit.User{Username: "ken"}, Tags: []*conduit.Tag{{Name: "sql"}},
+		CreatedAt: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+func openCorpus(t *testing.T, path string) *Index {
+	t.Helper()
+
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", path, err)
+	}
+
+	for _, a := range corpus {
+		if err := idx.Index(context.Background(), a); err != nil {
+			t.Fatalf("Index(%s): %v", a.Slug, err)
+		}
+	}
+
+	return idx
+}
+
+func slugs(result *conduit.SearchResult) []string {
+	s := make([]string, 0, len(result.Hits))
+	for _, h := range result.Hits {
+		s = append(s, h.Slug)
+	}
+	return s
+}
+
+func strp(s string) *string { return &s }
+
+func TestQuery(t *testing.T) {
+	idx := openCorpus(t, "")
+
+	tests := []struct {
+		name  string
+		query conduit.SearchQuery
+		slugs []string
+		total int
+	}{
+		{"title ranks first", conduit.SearchQuery{Text: "go"}, []string{"go-generics", "go-errors", "sql-tips"}, 3},
+		{"all terms must match", conduit.SearchQuery{Text: "go errors"}, []string{"go-errors"}, 1},
+		{"no match", conduit.SearchQuery{Text: "rust"}, []string{}, 0},
+		{"only stop words", conduit.SearchQuery{Text: "the and of"}, []string{}, 0},
+		{"case insensitive", conduit.SearchQuery{Text: "SQL"}, []string{"sql-tips"}, 1},
+		{"tag filter ignores case", conduit.SearchQuery{Text: "go", Tag: strp("generics")}, []string{"go-generics"}, 1},
+		{"author filter", conduit.SearchQuery{Text: "go", AuthorUsername: strp("ken")}, []string{"sql-tips"}, 1},
+		{"limit", conduit.SearchQuery{Text: "go", Limit: 1}, []string{"go-generics"}, 3},
+		{"offset", conduit.SearchQuery{Text: "go", Limit: 1, Offset: 1}, []string{"go-errors"}, 3},
+		{"offset past the end", conduit.SearchQuery{Text: "go", Offset: 5}, []string{}, 3},
+		{"negative offset", conduit.SearchQuery{Text: "go", Limit: 1, Offset: -1}, []string{"go-generics"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := idx.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+
+			if got := slugs(result); !reflect.DeepEqual(got, tt.slugs) {
+				t.Errorf("hits = %v, want %v", got, tt.slugs)
+			}
+
+			if result.Total != tt.total {
+				t.Errorf("total = %d, want %d", result.Total, tt.total)
+			}
+		})
+	}
+}
+
+func TestQueryCountsRepeatedTermsOnce(t *testing.T) {
+	idx := openCorpus(t, "")
+
+	once, _ := idx.Query(context.Background(), conduit.SearchQuery{Text: "go"})
+	twice, _ := idx.Query(context.Background(), conduit.SearchQuery{Text: "go Go go"})
+
+	for i := range once.Hits {
+		if once.Hits[i].Score != twice.Hits[i].Score {
+			t.Errorf("%s scores %v for %q, want %v", twice.Hits[i].Slug, twice.Hits[i].Score, "go Go go", once.Hits[i].Score)
+		}
+	}
+}
+
+func TestQueryFacets(t *testing.T) {
+	idx := openCorpus(t, "")
+
+	// facets count every match, not only the page
+	result, err := idx.Query(context.Background(), conduit.SearchQuery{Text: "go", Limit: 1})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+
+	want := map[string][]conduit.FacetCount{
+		"tag":    {{Value: "go", Count: 2}, {Value: "Generics", Count: 1}, {Value: "sql", Count: 1}},
+		"author": {{Value: "rob", Count: 2}, {Value: "ken", Count: 1}},
+	}
+
+	if !reflect.DeepEqual(result.Facets, want) {
+		t.Errorf("facets = %v, want %v", result.Facets, want)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	idx := openCorpus(t, "")
+
+	if err := idx.Delete(context.Background(), corpus[1]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	result, _ := idx.Query(context.Background(), conduit.SearchQuery{Text: "errors"})
+	if result.Total != 0 {
+		t.Errorf("deleted article still found: %v", slugs(result))
+	}
+
+	if _, ok := idx.postings["wrap"]; ok {
+		t.Error("postings of the deleted article were kept")
+	}
+}
+
+func TestIndexReplacesArticle(t *testing.T) {
+	idx := openCorpus(t, "")
+
+	edited := *corpus[2]
+	edited.Title = "Postgres tips"
+	if err := idx.Index(context.Background(), &edited); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+
+	if result, _ := idx.Query(context.Background(), conduit.SearchQuery{Text: "sql"}); result.Total != 0 {
+		t.Errorf("old title still found: %v", slugs(result))
+	}
+
+	if result, _ := idx.Query(context.Background(), conduit.SearchQuery{Text: "postgres"}); result.Total != 1 {
+		t.Errorf("new title not found")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("filler ", 40) + "needle " + strings.Repeat("filler ", 40)
+
+	tests := []struct {
+		name  string
+		body  string
+		terms []string
+		want  string
+	}{
+		{"wraps matches", "Go is fun, go!", []string{"go"}, "<b>Go</b> is fun, <b>go!</b>"},
+		{"escapes the body", "<script>go()</script> & go", []string{"go"}, "<b>&lt;script&gt;go()&lt;/script&gt;</b> &amp; <b>go</b>"},
+		{"no match keeps the start", "one two three", []string{"four"}, "one two three"},
+		{"window around the first match", long, []string{"needle"}, strings.TrimSpace(strings.Repeat("filler ", highlightWords/2) + "<b>needle</b> " + strings.Repeat("filler ", highlightWords/2-1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.body, tt.terms); got != tt.want {
+				t.Errorf("highlight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlushAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.gob")
+
+	idx := openCorpus(t, path)
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("index written before Flush: %v", err)
+	}
+
+	if err := idx.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if idx.dirty {
+		t.Error("index still dirty after Flush")
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	want, _ := idx.Query(context.Background(), conduit.SearchQuery{Text: "go"})
+	got, _ := reopened.Query(context.Background(), conduit.SearchQuery{Text: "go"})
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened index answers %v, want %v", slugs(got), slugs(want))
+	}
+}
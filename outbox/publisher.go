@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var (
+	_ conduit.EventPublisher = (*MemoryPublisher)(nil)
+	_ conduit.EventPublisher = (*LogPublisher)(nil)
+)
+
+// MemoryPublisher keeps the events it is given, for tests and for code
+// running in the same process.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []*conduit.DomainEvent
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event *conduit.DomainEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the events published so far, oldest first.
+func (p *MemoryPublisher) Events() []*conduit.DomainEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*conduit.DomainEvent(nil), p.events...)
+}
+
+// LogPublisher writes one line per event.
+type LogPublisher struct {
+	logger *log.Logger
+}
+
+func NewLogPublisher(logger *log.Logger) *LogPublisher {
+	return &LogPublisher{logger}
+}
+
+func (p *LogPublisher) Publish(_ context.Context, event *conduit.DomainEvent) error {
+	p.logger.Printf("event %d %s %s/%d %s", event.ID, event.Kind, event.AggregateType, event.AggregateID, event.Payload)
+	return nil
+}
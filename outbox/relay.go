@@ -0,0 +1,133 @@
+// Package outbox relays the domain events recorded by the postgres services
+// to an EventPublisher.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// sweepInterval is how often published events older than the retention are
+// deleted.
+const sweepInterval = time.Hour
+
+// Relay publishes outbox events at least once, in order within each
+// aggregate. An event that fails to publish is retried on the next run,
+// together with the events of its aggregate recorded after it, while the
+// events of other aggregates go on being published.
+type Relay struct {
+	Service   conduit.OutboxService
+	Publisher conduit.EventPublisher
+
+	// BatchSize bounds the events a run tries to publish.
+	BatchSize int
+
+	// Retention is how long published events are kept. Zero keeps them.
+	Retention time.Duration
+}
+
+func NewRelay(service conduit.OutboxService, publisher conduit.EventPublisher) *Relay {
+	return &Relay{Service: service, Publisher: publisher, BatchSize: 100, Retention: 7 * 24 * time.Hour}
+}
+
+// Run relays every interval until ctx is done. Full batches are followed by
+// the next one right away.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var swept time.Time
+
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err != nil {
+			log.Printf("cannot relay domain events: %v", err)
+		}
+
+		if r.Retention > 0 && time.Since(swept) >= sweepInterval {
+			if _, err := r.Sweep(ctx); err != nil {
+				log.Printf("cannot delete published domain events: %v", err)
+			}
+			swept = time.Now()
+		}
+
+		if err == nil && n == r.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes one batch and returns how many events were published.
+// The aggregate of an event that fails is left out of the rest of the run,
+// and the run reads on past it so it never blocks the others.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	var publishErr error
+
+	n, err := r.Service.RelayDomainEvents(ctx, func(pending conduit.PendingDomainEvents) ([]uint64, error) {
+		published := make([]uint64, 0, r.BatchSize)
+		held := make([]string, 0)
+		var afterID uint64
+
+		for attempts := 0; attempts < r.BatchSize; {
+			events, err := pending(afterID, held, r.BatchSize-attempts)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(events) == 0 {
+				break
+			}
+
+			for _, e := range events {
+				afterID = e.ID
+
+				if containsString(held, e.Aggregate()) {
+					continue
+				}
+
+				attempts++
+
+				if err := r.Publisher.Publish(ctx, e); err != nil {
+					held = append(held, e.Aggregate())
+					if publishErr == nil {
+						publishErr = fmt.Errorf("cannot publish event %d: %w", e.ID, err)
+					}
+					continue
+				}
+
+				published = append(published, e.ID)
+			}
+		}
+
+		return published, nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	return n, publishErr
+}
+
+// Sweep deletes the events published longer ago than the retention.
+func (r *Relay) Sweep(ctx context.Context) (int, error) {
+	return r.Service.DeletePublishedDomainEvents(ctx, time.Now().Add(-r.Retention))
+}
+
+func containsString(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
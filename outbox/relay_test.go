@@ -0,0 +1,174 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// memoryOutbox keeps the outbox in a slice and follows the contract of
+// conduit.OutboxService, marking nothing when relay or the commit fails.
+type memoryOutbox struct {
+	events     []*conduit.DomainEvent
+	published  map[uint64]bool
+	failCommit bool
+	before     time.Time
+}
+
+func newMemoryOutbox(events ...*conduit.DomainEvent) *memoryOutbox {
+	return &memoryOutbox{events: events, published: make(map[uint64]bool)}
+}
+
+func (mo *memoryOutbox) RelayDomainEvents(ctx context.Context, relay func(conduit.PendingDomainEvents) ([]uint64, error)) (int, error) {
+	ids, err := relay(func(afterID uint64, skip []string, limit int) ([]*conduit.DomainEvent, error) {
+		events := make([]*conduit.DomainEvent, 0)
+		for _, e := range mo.events {
+			if len(events) == limit {
+				break
+			}
+			if e.ID > afterID && !mo.published[e.ID] && !containsString(skip, e.Aggregate()) {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if mo.failCommit {
+		return 0, errors.New("commit failed")
+	}
+
+	for _, id := range ids {
+		mo.published[id] = true
+	}
+
+	return len(ids), nil
+}
+
+func (mo *memoryOutbox) DeletePublishedDomainEvents(ctx context.Context, before time.Time) (int, error) {
+	mo.before = before
+	return 0, nil
+}
+
+// flakyPublisher fails for the aggregates in down and hands the rest to a
+// MemoryPublisher.
+type flakyPublisher struct {
+	*MemoryPublisher
+	down map[string]bool
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, e *conduit.DomainEvent) error {
+	if p.down[e.Aggregate()] {
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryPublisher.Publish(ctx, e)
+}
+
+func articleEvent(id uint64, articleID uint) *conduit.DomainEvent {
+	return &conduit.DomainEvent{ID: id, AggregateType: conduit.AggregateArticle, AggregateID: articleID, Kind: conduit.ArticleUpdated}
+}
+
+func publishedIDs(p *MemoryPublisher) []uint64 {
+	ids := make([]uint64, 0)
+	for _, e := range p.Events() {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestRelayOnceHoldsBackFailingAggregate(t *testing.T) {
+	ctx := context.Background()
+
+	service := newMemoryOutbox(articleEvent(1, 7), articleEvent(2, 8), articleEvent(3, 7), articleEvent(4, 8))
+	publisher := &flakyPublisher{NewMemoryPublisher(), map[string]bool{"article/7": true}}
+	r := NewRelay(service, publisher)
+
+	n, err := r.RelayOnce(ctx)
+	if err == nil {
+		t.Error("RelayOnce did not report the failed event")
+	}
+
+	if n != 2 {
+		t.Errorf("RelayOnce published %d events, want 2", n)
+	}
+
+	if got, want := publishedIDs(publisher.MemoryPublisher), []uint64{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("published %v, want %v", got, want)
+	}
+
+	delete(publisher.down, "article/7")
+
+	if _, err := r.RelayOnce(ctx); err != nil {
+		t.Fatalf("RelayOnce: %v", err)
+	}
+
+	if got, want := publishedIDs(publisher.MemoryPublisher), []uint64{2, 4, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("published %v, want the held events in order after the others: %v", got, want)
+	}
+}
+
+func TestRelayOnceReadsPastHeldEvents(t *testing.T) {
+	service := newMemoryOutbox(
+		articleEvent(1, 7), articleEvent(2, 7), articleEvent(3, 7), articleEvent(4, 7),
+		articleEvent(5, 8),
+	)
+	publisher := &flakyPublisher{NewMemoryPublisher(), map[string]bool{"article/7": true}}
+
+	r := NewRelay(service, publisher)
+	r.BatchSize = 2
+
+	n, _ := r.RelayOnce(context.Background())
+
+	if got, want := publishedIDs(publisher.MemoryPublisher), []uint64{5}; n != 1 || !reflect.DeepEqual(got, want) {
+		t.Errorf("published %v (n = %d), want %v past the events of the failing aggregate", got, n, want)
+	}
+}
+
+func TestRelayOnceRepublishesUnmarkedEvents(t *testing.T) {
+	ctx := context.Background()
+
+	service := newMemoryOutbox(articleEvent(1, 7), articleEvent(2, 8))
+	service.failCommit = true
+
+	publisher := NewMemoryPublisher()
+	r := NewRelay(service, publisher)
+
+	if _, err := r.RelayOnce(ctx); err == nil {
+		t.Fatal("RelayOnce did not report the failed commit")
+	}
+
+	service.failCommit = false
+
+	if n, err := r.RelayOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("RelayOnce = %d, %v; want 2, nil", n, err)
+	}
+
+	if got, want := publishedIDs(publisher), []uint64{1, 2, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("published %v, want every event again after the lost commit: %v", got, want)
+	}
+
+	if n, err := r.RelayOnce(ctx); err != nil || n != 0 {
+		t.Errorf("RelayOnce after marking = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestSweepKeepsRetention(t *testing.T) {
+	service := newMemoryOutbox()
+	r := NewRelay(service, NewMemoryPublisher())
+	r.Retention = time.Hour
+
+	start := time.Now()
+	if _, err := r.Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+
+	if service.before.Before(start.Add(-time.Hour)) || service.before.After(time.Now().Add(-time.Hour)) {
+		t.Errorf("Sweep deleted events published before %v, want an hour ago", service.before)
+	}
+}
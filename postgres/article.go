@@ -2,15 +2,26 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/gosimple/slug"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
 )
 
 var _ conduit.ArticleService = (*ArticleService)(nil)
 
+// articleColumns lists the columns scanned into conduit.Article. The generated
+// search_vector column is left out as it has no struct field.
+const articleColumns = "id, title, body, body_html, description, slug, author_id, status, published_at, publish_at, word_count, reading_time_minutes, created_at, updated_at"
+
+// favoritesCountColumn sorts articles by how many users favorited them.
+const favoritesCountColumn = "(SELECT COUNT(*) FROM favorites WHERE article_id = articles.id)"
+
 type ArticleService struct {
 	db *DB
 }
@@ -34,6 +45,22 @@ func (as *ArticleService) CreateArticle(ctx context.Context, article *conduit.Ar
 	return tx.Commit()
 }
 
+func (as *ArticleService) ArticleBySlug(ctx context.Context, slug string) (*conduit.Article, error) {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	article, err := findOneArticle(ctx, tx, conduit.ArticleFilter{Slug: &slug, AnyStatus: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return article, tx.Commit()
+}
+
 func (as *ArticleService) Articles(ctx context.Context, filter conduit.ArticleFilter) ([]*conduit.Article, error) {
 	tx, err := as.db.BeginTxx(ctx, nil)
 	if err != nil {
@@ -65,23 +92,194 @@ func (as *ArticleService) ArticleFeed(ctx context.Context, user *conduit.User, f
 	return articles, tx.Commit()
 }
 
+func (as *ArticleService) UpdateArticle(ctx context.Context, article *conduit.Article, patch conduit.ArticlePatch) error {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := updateArticle(ctx, tx, article, patch); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (as *ArticleService) DeleteArticle(ctx context.Context, article *conduit.Article) error {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := deleteArticle(ctx, tx, article); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (as *ArticleService) FavoriteArticle(ctx context.Context, user *conduit.User, article *conduit.Article) error {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "INSERT INTO favorites (article_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+
+	res, err := tx.ExecContext(ctx, query, article.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		n := conduit.Notification{
+			Kind:      conduit.NotificationFavorite,
+			UserID:    article.AuthorID,
+			ActorID:   user.ID,
+			ArticleID: &article.ID,
+		}
+
+		if err := notify(ctx, tx, &n); err != nil {
+			return err
+		}
+
+		change := favoriteChange{ArticleID: article.ID, UserID: user.ID}
+		if err := recordDomainEvent(ctx, tx, conduit.AggregateArticle, article.ID, conduit.ArticleFavorited, change); err != nil {
+			return err
+		}
+	}
+
+	if err := attachArticleFavorites(ctx, tx, article); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (as *ArticleService) UnfavoriteArticle(ctx context.Context, user *conduit.User, article *conduit.Article) error {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "DELETE FROM favorites WHERE article_id = $1 AND user_id = $2"
+
+	res, err := tx.ExecContext(ctx, query, article.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		change := favoriteChange{ArticleID: article.ID, UserID: user.ID}
+		if err := recordDomainEvent(ctx, tx, conduit.AggregateArticle, article.ID, conduit.ArticleUnfavorited, change); err != nil {
+			return err
+		}
+	}
+
+	if err := attachArticleFavorites(ctx, tx, article); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (as *ArticleService) PublishScheduledArticles(ctx context.Context, now time.Time) ([]*conduit.Article, error) {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	articles, err := publishScheduledArticles(ctx, tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range articles {
+		if err := notifyMentions(ctx, tx, a.ID); err != nil {
+			return nil, err
+		}
+
+		if err := publishArticleEvent(ctx, tx, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return articles, tx.Commit()
+}
+
+func (as *ArticleService) CurrentSlug(ctx context.Context, previousSlug string) (string, error) {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	SELECT a.slug FROM articles a JOIN article_slugs s ON s.article_id = a.id
+	WHERE s.slug = $1
+	`
+
+	var current string
+	if err := tx.GetContext(ctx, &current, query, previousSlug); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", conduit.ErrNotFound
+		}
+		return "", err
+	}
+
+	return current, tx.Commit()
+}
+
 func createArticle(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) error {
+	if article.Status == "" {
+		article.SetStatus(conduit.ArticleStatusPublished, nil, time.Now())
+	}
+
+	if article.Slug == "" {
+		s, err := uniqueArticleSlug(ctx, tx, article.Title, 0)
+		if err != nil {
+			return err
+		}
+		article.Slug = s
+	}
+
 	query := `
-	INSERT INTO articles (title, body, description, author_id, slug) 
-	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at
+	INSERT INTO articles (title, body, body_html, description, author_id, slug, status, published_at, publish_at, word_count, reading_time_minutes)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, author_id, created_at, updated_at
 	`
 
 	args := []interface{}{
 		article.Title,
 		article.Body,
+		article.BodyHTML,
 		article.Description,
 		article.Author.ID,
 		article.Slug,
+		article.Status,
+		article.PublishedAt,
+		article.PublishAt,
+		article.WordCount,
+		article.ReadingTime,
 	}
 
-	err := tx.QueryRowxContext(ctx, query, args...).Scan(&article.ID, &article.CreatedAt, &article.UpdatedAt)
+	err := tx.QueryRowxContext(ctx, query, args...).Scan(&article.ID, &article.AuthorID, &article.CreatedAt, &article.UpdatedAt)
 	if err != nil {
-		return err
+		return articleWriteError(err)
 	}
 
 	tags := make([]string, len(article.Tags))
@@ -94,9 +292,246 @@ func createArticle(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) e
 		return err
 	}
 
+	// aliases may have resolved to other tags than the ones given
+	if article.Tags, err = findArticleTags(ctx, tx, article); err != nil {
+		return err
+	}
+
+	if err := recordArticleChange(ctx, tx, conduit.ArticleCreated, article); err != nil {
+		return err
+	}
+
+	if err := recordMentions(ctx, tx, article.Author.ID, article.ID, 0, article.Body); err != nil {
+		return err
+	}
+
+	if err := notifyMentions(ctx, tx, article.ID); err != nil {
+		return err
+	}
+
+	if article.IsPublished() {
+		if err := publishArticleEvent(ctx, tx, article); err != nil {
+			return err
+		}
+	}
+
+	return recordArticleRevision(ctx, tx, article, article.Author)
+}
+
+func updateArticle(ctx context.Context, tx *sqlx.Tx, article *conduit.Article, patch conduit.ArticlePatch) error {
+	previousSlug := article.Slug
+	publishedBefore := article.PublishedAt != nil
+
+	if v := patch.Title; v != nil && *v != article.Title {
+		s, err := uniqueArticleSlug(ctx, tx, *v, article.ID)
+		if err != nil {
+			return err
+		}
+		article.Title, article.Slug = *v, s
+	}
+
+	if v := patch.Body; v != nil {
+		article.Body = *v
+	}
+
+	if v := patch.BodyHTML; v != nil {
+		article.BodyHTML = *v
+	}
+
+	if v := patch.WordCount; v != nil {
+		article.WordCount = *v
+		article.ReadingTime = conduit.ReadingTime(*v)
+	}
+
+	if v := patch.Description; v != nil {
+		article.Description = *v
+	}
+
+	if v := patch.Status; v != nil {
+		if err := article.SetStatus(*v, patch.PublishAt, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	args := []interface{}{
+		article.Title,
+		article.Body,
+		article.BodyHTML,
+		article.Description,
+		article.Slug,
+		article.Status,
+		article.PublishedAt,
+		article.PublishAt,
+		article.WordCount,
+		article.ReadingTime,
+		article.ID,
+	}
+
+	query := `
+	UPDATE articles
+	SET title = $1, body = $2, body_html = $3, description = $4, slug = $5,
+		status = $6, published_at = $7, publish_at = $8,
+		word_count = $9, reading_time_minutes = $10, updated_at = NOW()
+	WHERE id = $11
+	RETURNING updated_at`
+
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&article.UpdatedAt); err != nil {
+		return articleWriteError(err)
+	}
+
+	if article.Slug != previousSlug {
+		if err := moveArticleSlug(ctx, tx, article, previousSlug); err != nil {
+			return err
+		}
+	}
+
+	if patch.Tags != nil {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = $1", article.ID); err != nil {
+			return err
+		}
+
+		if err := setArticleTags(ctx, tx, article, patch.Tags); err != nil {
+			return err
+		}
+
+		tags, err := findArticleTags(ctx, tx, article)
+		if err != nil {
+			return err
+		}
+
+		article.Tags = tags
+	}
+
+	if patch.Body != nil {
+		authorID := article.AuthorID
+		if patch.Editor != nil {
+			authorID = patch.Editor.ID
+		}
+
+		if err := recordMentions(ctx, tx, authorID, article.ID, 0, article.Body); err != nil {
+			return err
+		}
+	}
+
+	// also catches the mentions of a draft that is published now
+	if err := notifyMentions(ctx, tx, article.ID); err != nil {
+		return err
+	}
+
+	if err := recordArticleChange(ctx, tx, conduit.ArticleUpdated, article); err != nil {
+		return err
+	}
+
+	contentChanged := patch.Title != nil || patch.Body != nil || patch.Description != nil || patch.Tags != nil
+
+	switch {
+	case !publishedBefore && article.IsPublished():
+		if err := publishArticleEvent(ctx, tx, article); err != nil {
+			return err
+		}
+	case article.IsPublished() && contentChanged:
+		data, err := newArticleEvent(ctx, tx, article)
+		if err != nil {
+			return err
+		}
+
+		if err := enqueueWebhookDeliveries(ctx, tx, conduit.WebhookArticleUpdated, article.AuthorID, data); err != nil {
+			return err
+		}
+	}
+
+	if contentChanged {
+		return recordArticleRevision(ctx, tx, article, patch.Editor)
+	}
+
 	return nil
 }
 
+// uniqueArticleSlug derives a slug from title, appending -2, -3, ... when it
+// is taken by another article, now or in the past. Slugs the article itself
+// used before are free for it to take back.
+func uniqueArticleSlug(ctx context.Context, tx *sqlx.Tx, title string, articleID uint) (string, error) {
+	base := slug.Make(title)
+	if base == "" {
+		base = "article"
+	}
+
+	query := `
+	SELECT slug FROM articles WHERE (slug = $1 OR slug LIKE $2) AND id <> $3
+	UNION
+	SELECT slug FROM article_slugs WHERE (slug = $1 OR slug LIKE $2) AND article_id <> $3
+	`
+
+	taken := make([]string, 0)
+	if err := tx.SelectContext(ctx, &taken, query, base, escapeLike(base)+"-%", articleID); err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, s := range taken {
+		used[s] = true
+	}
+
+	candidate := base
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return candidate, nil
+}
+
+// moveArticleSlug keeps previousSlug in the slug history so old links can be
+// redirected to the article's current slug.
+func moveArticleSlug(ctx context.Context, tx *sqlx.Tx, article *conduit.Article, previousSlug string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_slugs WHERE slug = $1", article.Slug); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO article_slugs (slug, article_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	_, err := tx.ExecContext(ctx, query, previousSlug, article.ID)
+	return err
+}
+
+// articleWriteError maps the unique slug violation a concurrent write can
+// still cause to ErrDuplicateSlug.
+func articleWriteError(err error) error {
+	if err.Error() == `pq: duplicate key value violates unique constraint "articles_slug_key"` {
+		return conduit.ErrDuplicateSlug
+	}
+	return err
+}
+
+func deleteArticle(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) error {
+	query := "DELETE FROM articles WHERE id = $1"
+
+	if _, err := tx.ExecContext(ctx, query, article.ID); err != nil {
+		return err
+	}
+
+	return recordArticleChange(ctx, tx, conduit.ArticleDeleted, article)
+}
+
+func publishScheduledArticles(ctx context.Context, tx *sqlx.Tx, now time.Time) ([]*conduit.Article, error) {
+	query := `
+	UPDATE articles
+	SET status = 'published', published_at = COALESCE(published_at, publish_at), publish_at = NULL, updated_at = NOW()
+	WHERE status = 'scheduled' AND publish_at <= $1
+	RETURNING ` + articleColumns
+
+	return queryArticles(ctx, tx, query, now)
+}
+
+func findOneArticle(ctx context.Context, tx *sqlx.Tx, filter conduit.ArticleFilter) (*conduit.Article, error) {
+	as, err := findArticles(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(as) == 0 {
+		return nil, conduit.ErrNotFound
+	}
+
+	return as[0], nil
+}
+
 func findArticles(ctx context.Context, tx *sqlx.Tx, filter conduit.ArticleFilter) ([]*conduit.Article, error) {
 	where, args := []string{}, []interface{}{}
 	argPosition := 0 // used to set correct postgres argument enums i.e $1, $2
@@ -106,9 +541,17 @@ func findArticles(ctx context.Context, tx *sqlx.Tx, filter conduit.ArticleFilter
 		where, args = append(where, fmt.Sprintf("id = $%d", argPosition)), append(args, *v)
 	}
 
+	if v := filter.IDs; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("id = ANY($%d)", argPosition)), append(args, pq.Array(v))
+	}
+
 	if v := filter.AuthorID; v != nil {
 		argPosition++
-		where, args = append(where, fmt.Sprintf("author_id = $%d", argPosition)), append(args, *v)
+		clause := `(author_id = $%[1]d OR id IN (
+			select article_id from article_coauthors where status = 'accepted' and user_id = $%[1]d)
+			)`
+		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
 	}
 
 	if v := filter.Slug; v != nil {
@@ -131,7 +574,10 @@ func findArticles(ctx context.Context, tx *sqlx.Tx, filter conduit.ArticleFilter
 
 	if v := filter.AuthorUsername; v != nil {
 		argPosition++
-		clause := "author_id = (select id from users where username = $%d)"
+		clause := `(author_id = (select id from users where username = $%[1]d) OR id IN (
+			select article_id from article_coauthors where status = 'accepted' and user_id = (
+			select id from users where username = $%[1]d))
+			)`
 		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
 	}
 
@@ -143,18 +589,86 @@ func findArticles(ctx context.Context, tx *sqlx.Tx, filter conduit.ArticleFilter
 		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
 	}
 
-	query := "SELECT * from articles" + formatWhereClause(where) + " ORDER BY created_at DESC"
+	if v := filter.SeriesSlug; v != nil {
+		argPosition++
+		clause := `id IN (select article_id from series_articles where series_id = (
+			select id from series where slug = $%d)
+			)`
+		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
+	}
+
+	bookmarkedByPosition := 0
+	if v := filter.BookmarkedBy; v != nil {
+		argPosition++
+		bookmarkedByPosition = argPosition
+		where, args = append(where, fmt.Sprintf("id IN (select article_id from bookmarks where user_id = $%d)", argPosition)), append(args, *v)
+
+		if f := filter.BookmarkFolder; f != nil {
+			argPosition++
+			clause := "id IN (select article_id from bookmarks where user_id = $%d and folder = $%d)"
+			where, args = append(where, fmt.Sprintf(clause, bookmarkedByPosition, argPosition)), append(args, *f)
+		}
+	}
+
+	if v := filter.PublishedSince; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("published_at > $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.Status; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("status = $%d", argPosition)), append(args, *v)
+	} else if !filter.AnyStatus {
+		where = append(where, "status = 'published'")
+	}
+
+	columns, orderBy := articleColumns, " ORDER BY created_at DESC"
+
+	if v := filter.Query; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("search_vector @@ websearch_to_tsquery('english', $%d)", argPosition)), append(args, *v)
+		columns += fmt.Sprintf(`,
+			ts_rank(search_vector, websearch_to_tsquery('english', $%[1]d)) AS rank,
+			ts_headline('english', body, websearch_to_tsquery('english', $%[1]d), '`+headlineOptions+`') AS highlight`,
+			argPosition)
+		orderBy = " ORDER BY rank DESC, created_at DESC"
+	}
+
+	if filter.SeriesSlug != nil {
+		orderBy = " ORDER BY (select position from series_articles where article_id = articles.id) ASC"
+	}
+
+	if filter.BookmarkedBy != nil {
+		orderBy = fmt.Sprintf(" ORDER BY (select created_at from bookmarks where article_id = articles.id and user_id = $%d) DESC", bookmarkedByPosition)
+	}
+
+	switch filter.Sort {
+	case conduit.SortReadingTime:
+		orderBy = " ORDER BY reading_time_minutes ASC, created_at DESC"
+	case conduit.SortReadingTimeDesc:
+		orderBy = " ORDER BY reading_time_minutes DESC, created_at DESC"
+	case conduit.SortFavoritesDesc:
+		orderBy = " ORDER BY " + favoritesCountColumn + " DESC, created_at DESC"
+	}
+
+	query := "SELECT " + columns + " from articles" + formatWhereClause(where) + orderBy + " " + formatLimitOffset(filter.Limit, filter.Offset)
 	articles, err := queryArticles(ctx, tx, query, args...)
 	if err != nil {
 		return articles, err
 	}
 
+	if filter.Query != nil {
+		for _, a := range articles {
+			a.Highlight = headlineHTML(a.Highlight)
+		}
+	}
+
 	return articles, nil
 }
 
 func setArticleTags(ctx context.Context, tx *sqlx.Tx, article *conduit.Article, tags []string) error {
 	for _, v := range tags {
-		tag, err := findTagByName(ctx, tx, v)
+		tag, err := resolveTagByName(ctx, tx, v)
 		if err != nil {
 			switch {
 			case errors.Is(err, conduit.ErrNotFound):
@@ -179,7 +693,8 @@ func setArticleTags(ctx context.Context, tx *sqlx.Tx, article *conduit.Article,
 }
 
 func associateArticleWithTag(ctx context.Context, tx *sqlx.Tx, article *conduit.Article, tag *conduit.Tag) error {
-	query := "INSERT INTO article_tags (article_id, tag_id) VALUES ($1, $2)"
+	// several names may resolve to the same tag through aliases
+	query := "INSERT INTO article_tags (article_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
 
 	_, err := tx.ExecContext(ctx, query, article.ID, tag.ID)
 	if err != nil {
@@ -204,18 +719,30 @@ func attachArticleAssociations(ctx context.Context, tx *sqlx.Tx, article *condui
 
 	article.Author = user
 
-	query := `SELECT * from users WHERE id IN (
-		SELECT user_id FROM favorites WHERE article_id = $1
-	)`
+	coAuthors, err := findArticleCoAuthors(ctx, tx, article)
+	if err != nil {
+		return fmt.Errorf("cannot find article co-authors: %w", err)
+	}
 
-	favorites := make([]*conduit.User, 0)
+	article.CoAuthors = coAuthors
 
-	if err := findMany(ctx, tx, &favorites, query, article.ID); err != nil {
+	reactions, err := findReactionCounts(ctx, tx, article.ReactionTarget())
+	if err != nil {
+		return fmt.Errorf("cannot find article reactions: %w", err)
+	}
+
+	article.Reactions = reactions
+
+	if err := attachArticleFavorites(ctx, tx, article); err != nil {
 		return err
 	}
 
-	article.FavoritedBy = favorites
-	article.FavoritesCount = int64(len(favorites))
+	series, err := findArticleSeries(ctx, tx, article)
+	if err != nil {
+		return fmt.Errorf("cannot find article series: %w", err)
+	}
+
+	article.Series = series
 
 	return nil
 }
@@ -233,14 +760,51 @@ func findArticleTags(ctx context.Context, tx *sqlx.Tx, article *conduit.Article)
 	return tags, nil
 }
 
+// getArticlesFromUserFollowings returns the articles written by authors the
+// user follows and those carrying tags the user follows. Matching both only
+// returns an article once.
 func getArticlesFromUserFollowings(ctx context.Context, tx *sqlx.Tx, user *conduit.User, filter conduit.ArticleFilter) ([]*conduit.Article, error) {
+	byAuthors := `(author_id IN (
+		SELECT following_id from followings WHERE follower_id = $1
+	) OR id IN (
+		SELECT article_id FROM article_coauthors WHERE status = 'accepted' AND user_id IN (
+			SELECT following_id from followings WHERE follower_id = $1
+		)
+	))`
+	byTags := `id IN (
+		SELECT article_id FROM article_tags WHERE tag_id IN (
+			SELECT tag_id FROM tag_followings WHERE follower_id = $1
+		)
+	)`
+
+	var where string
+	switch filter.FeedSource {
+	case conduit.FeedSourceAuthors:
+		where = byAuthors
+	case conduit.FeedSourceTags:
+		where = byTags
+	default:
+		where = "(" + byAuthors + " OR " + byTags + ")"
+	}
+
+	args := []interface{}{user.ID}
+
+	if v := filter.PublishedSince; v != nil {
+		args = append(args, *v)
+		where += fmt.Sprintf(" AND published_at > $%d", len(args))
+	}
+
+	orderBy := "created_at DESC"
+	if filter.Sort == conduit.SortFavoritesDesc {
+		orderBy = favoritesCountColumn + " DESC, created_at DESC"
+	}
+
 	query := `
-	SELECT * from articles as a WHERE author_id IN (
-		SELECT following_id from followings WHERE follower_id = $1 
-	) ORDER BY a.created_at DESC
+	SELECT ` + articleColumns + ` from articles WHERE status = 'published' AND ` + where + `
+	ORDER BY ` + orderBy + `
 	` + formatLimitOffset(filter.Limit, filter.Offset)
 
-	return queryArticles(ctx, tx, query, user.ID)
+	return queryArticles(ctx, tx, query, args...)
 }
 
 func queryArticles(ctx context.Context, tx *sqlx.Tx, query string, args ...interface{}) ([]*conduit.Article, error) {
@@ -258,3 +822,20 @@ func queryArticles(ctx context.Context, tx *sqlx.Tx, query string, args ...inter
 
 	return articles, nil
 }
+
+func attachArticleFavorites(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) error {
+	query := `SELECT * from users WHERE id IN (
+		SELECT user_id FROM favorites WHERE article_id = $1
+	)`
+
+	favorites := make([]*conduit.User, 0)
+
+	if err := findMany(ctx, tx, &favorites, query, article.ID); err != nil {
+		return err
+	}
+
+	article.FavoritedBy = favorites
+	article.FavoritesCount = int64(len(favorites))
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.BookmarkService = (*BookmarkService)(nil)
+
+type BookmarkService struct {
+	db *DB
+}
+
+func NewBookmarkService(db *DB) *BookmarkService {
+	return &BookmarkService{db}
+}
+
+func (bs *BookmarkService) BookmarkArticle(ctx context.Context, user *conduit.User, article *conduit.Article, folder string) error {
+	tx, err := bs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO bookmarks (user_id, article_id, folder) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, article_id) DO UPDATE SET folder = EXCLUDED.folder
+	`
+
+	if _, err := tx.ExecContext(ctx, query, user.ID, article.ID, folder); err != nil {
+		return err
+	}
+
+	article.Bookmarked = true
+
+	return tx.Commit()
+}
+
+func (bs *BookmarkService) UnbookmarkArticle(ctx context.Context, user *conduit.User, article *conduit.Article) error {
+	tx, err := bs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM bookmarks WHERE user_id = $1 AND article_id = $2", user.ID, article.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return conduit.ErrNotFound
+	}
+
+	article.Bookmarked = false
+
+	return tx.Commit()
+}
+
+func (bs *BookmarkService) BookmarkFolders(ctx context.Context, user *conduit.User) ([]*conduit.BookmarkFolder, error) {
+	tx, err := bs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	SELECT folder AS name, COUNT(*) AS count FROM bookmarks
+	WHERE user_id = $1 GROUP BY folder ORDER BY folder ASC
+	`
+
+	folders := make([]*conduit.BookmarkFolder, 0)
+	if err := findMany(ctx, tx, &folders, query, user.ID); err != nil {
+		return nil, err
+	}
+
+	return folders, tx.Commit()
+}
+
+func (bs *BookmarkService) SetBookmarked(ctx context.Context, user *conduit.User, articles ...*conduit.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	tx, err := bs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	ids := make([]uint, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+
+	query := "SELECT article_id FROM bookmarks WHERE user_id = $1 AND article_id = ANY($2)"
+
+	rows, err := tx.QueryContext(ctx, query, user.ID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	bookmarked := make(map[uint]bool)
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		bookmarked[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range articles {
+		a.Bookmarked = bookmarked[a.ID]
+	}
+
+	return tx.Commit()
+}
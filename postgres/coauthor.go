@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.CoAuthorService = (*CoAuthorService)(nil)
+
+type CoAuthorService struct {
+	db *DB
+}
+
+func NewCoAuthorService(db *DB) *CoAuthorService {
+	return &CoAuthorService{db}
+}
+
+func (cs *CoAuthorService) InviteCoAuthor(ctx context.Context, article *conduit.Article, user, invitedBy *conduit.User) (*conduit.CoAuthorInvitation, error) {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO article_coauthors (article_id, user_id, invited_by)
+	VALUES ($1, $2, $3) ON CONFLICT DO NOTHING
+	`
+
+	res, err := tx.ExecContext(ctx, query, article.ID, user.ID, invitedBy.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, conduit.ErrDuplicateCoAuthor
+	}
+
+	invitations, err := findCoAuthorInvitations(ctx, tx, "ac.article_id = $1 AND ac.user_id = $2", article.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations[0], tx.Commit()
+}
+
+func (cs *CoAuthorService) AcceptCoAuthorInvitation(ctx context.Context, article *conduit.Article, user *conduit.User) error {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	UPDATE article_coauthors SET status = 'accepted', accepted_at = NOW(), position = (
+		SELECT COALESCE(MAX(position), 0) + 1 FROM article_coauthors WHERE article_id = $1 AND status = 'accepted'
+	)
+	WHERE article_id = $1 AND user_id = $2 AND status = 'invited'
+	`
+
+	res, err := tx.ExecContext(ctx, query, article.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return conduit.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (cs *CoAuthorService) RemoveCoAuthor(ctx context.Context, article *conduit.Article, user *conduit.User) error {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM article_coauthors WHERE article_id = $1 AND user_id = $2", article.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return conduit.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (cs *CoAuthorService) ReorderCoAuthors(ctx context.Context, article *conduit.Article, userIDs []uint) error {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	coAuthors, err := findArticleCoAuthors(ctx, tx, article)
+	if err != nil {
+		return err
+	}
+
+	if len(coAuthors) != len(userIDs) {
+		return conduit.ErrNotFound
+	}
+
+	for i, id := range userIDs {
+		query := "UPDATE article_coauthors SET position = $1 WHERE article_id = $2 AND user_id = $3 AND status = 'accepted'"
+		res, err := tx.ExecContext(ctx, query, i+1, article.ID, id)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return conduit.ErrNotFound
+		}
+	}
+
+	if article.CoAuthors, err = findArticleCoAuthors(ctx, tx, article); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (cs *CoAuthorService) CoAuthorInvitations(ctx context.Context, user *conduit.User) ([]*conduit.CoAuthorInvitation, error) {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	invitations, err := findCoAuthorInvitations(ctx, tx, "ac.user_id = $1 AND ac.status = 'invited'", user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, tx.Commit()
+}
+
+// findArticleCoAuthors returns the accepted co-authors of the article in order.
+func findArticleCoAuthors(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) ([]*conduit.User, error) {
+	query := `
+	SELECT u.* FROM users u JOIN article_coauthors ac ON ac.user_id = u.id
+	WHERE ac.article_id = $1 AND ac.status = 'accepted'
+	ORDER BY ac.position ASC
+	`
+
+	users, err := queryUsers(ctx, tx, query, article.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range users {
+		followers, err := getFollowers(ctx, tx, u)
+		if err != nil {
+			return nil, err
+		}
+		u.Followers = followers
+	}
+
+	return users, nil
+}
+
+func findCoAuthorInvitations(ctx context.Context, tx *sqlx.Tx, where string, args ...interface{}) ([]*conduit.CoAuthorInvitation, error) {
+	query := `
+	SELECT ac.article_id, a.slug AS article_slug, a.title AS article_title, ac.user_id, u.username,
+		ac.invited_by, i.username AS invited_by_username, ac.status, ac.invited_at, ac.accepted_at
+	FROM article_coauthors ac
+	JOIN articles a ON a.id = ac.article_id
+	JOIN users u ON u.id = ac.user_id
+	JOIN users i ON i.id = ac.invited_by
+	WHERE ` + where + `
+	ORDER BY ac.invited_at DESC
+	`
+
+	invitations := make([]*conduit.CoAuthorInvitation, 0)
+	if err := findMany(ctx, tx, &invitations, query, args...); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
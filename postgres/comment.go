@@ -0,0 +1,351 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.CommentService = (*CommentService)(nil)
+
+type CommentService struct {
+	db       *DB
+	maxDepth int
+}
+
+// NewCommentService returns a CommentService that lets replies nest at most
+// maxDepth levels below a top-level comment.
+func NewCommentService(db *DB, maxDepth int) *CommentService {
+	if maxDepth <= 0 {
+		maxDepth = conduit.DefaultCommentMaxDepth
+	}
+
+	return &CommentService{db, maxDepth}
+}
+
+func (cs *CommentService) CreateComment(ctx context.Context, comment *conduit.Comment) error {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := createComment(ctx, tx, comment, cs.maxDepth); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (cs *CommentService) CommentByID(ctx context.Context, id uint) (*conduit.Comment, error) {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	comments, err := findComments(ctx, tx, conduit.CommentFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	} else if len(comments) == 0 {
+		return nil, conduit.ErrNotFound
+	}
+
+	return comments[0], tx.Commit()
+}
+
+func (cs *CommentService) Comments(ctx context.Context, filter conduit.CommentFilter) ([]*conduit.Comment, error) {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	comments, err := findComments(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, tx.Commit()
+}
+
+func (cs *CommentService) DeleteComment(ctx context.Context, comment *conduit.Comment) error {
+	tx, err := cs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := deleteComment(ctx, tx, comment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func createComment(ctx context.Context, tx *sqlx.Tx, comment *conduit.Comment, maxDepth int) error {
+	comment.Depth = 0
+
+	if comment.ParentID != nil {
+		var parent struct {
+			ArticleID uint `db:"article_id"`
+			Depth     int
+			Deleted   bool
+		}
+
+		query := "SELECT article_id, depth, deleted_at IS NOT NULL AS deleted FROM comments WHERE id = $1 FOR UPDATE"
+		if err := tx.QueryRowxContext(ctx, query, *comment.ParentID).StructScan(&parent); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return conduit.ErrNotFound
+			}
+			return err
+		}
+
+		if parent.ArticleID != comment.ArticleID || parent.Deleted {
+			return conduit.ErrNotFound
+		}
+
+		if parent.Depth+1 > maxDepth {
+			return conduit.ErrCommentTooDeep
+		}
+
+		comment.Depth = parent.Depth + 1
+	}
+
+	query := `
+	INSERT INTO comments (article_id, author_id, parent_id, depth, body)
+	VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at
+	`
+
+	args := []interface{}{comment.ArticleID, comment.Author.ID, comment.ParentID, comment.Depth, comment.Body}
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&comment.ID, &comment.CreatedAt); err != nil {
+		return err
+	}
+
+	comment.AuthorID = comment.Author.ID
+	comment.Reactions = conduit.NewReactions()
+
+	if err := recordCommentChange(ctx, tx, conduit.CommentCreated, comment); err != nil {
+		return err
+	}
+
+	if err := recordMentions(ctx, tx, comment.AuthorID, comment.ArticleID, comment.ID, comment.Body); err != nil {
+		return err
+	}
+
+	if err := announceComment(ctx, tx, conduit.CommentCreated, comment.ArticleID, comment.ID); err != nil {
+		return err
+	}
+
+	if err := notifyMentions(ctx, tx, comment.ArticleID); err != nil {
+		return err
+	}
+
+	var (
+		ownerID     uint
+		articleSlug string
+	)
+
+	query = "SELECT author_id, slug FROM articles WHERE id = $1"
+	if err := tx.QueryRowxContext(ctx, query, comment.ArticleID).Scan(&ownerID, &articleSlug); err != nil {
+		return err
+	}
+
+	n := conduit.Notification{
+		Kind:      conduit.NotificationComment,
+		UserID:    ownerID,
+		ActorID:   comment.AuthorID,
+		ArticleID: &comment.ArticleID,
+		CommentID: &comment.ID,
+	}
+
+	if err := notify(ctx, tx, &n); err != nil {
+		return err
+	}
+
+	// the payload is seen by nobody in particular; the comment itself is
+	// presented to its reader by the caller
+	payload := *comment
+	payload.SetAuthorProfile(&conduit.AnonymousUser)
+	data := commentEvent{articleSlug, &payload}
+
+	if err := enqueueWebhookDeliveries(ctx, tx, conduit.WebhookCommentCreated, ownerID, data); err != nil {
+		return err
+	}
+
+	if ownerID == comment.AuthorID {
+		return nil
+	}
+
+	return publishEvent(ctx, tx, ownerID, conduit.EventComment, data)
+}
+
+// deleteComment removes the comment, or blanks it out when replies still hang
+// off it. Placeholders left without replies are removed along the way.
+func deleteComment(ctx context.Context, tx *sqlx.Tx, comment *conduit.Comment) error {
+	// a reply takes a key share lock on its parent, so once the comment is
+	// locked no reply can slip in between the check and the delete
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM comments WHERE id = $1 FOR UPDATE", comment.ID); err != nil {
+		return err
+	}
+
+	var hasReplies bool
+
+	query := "SELECT EXISTS (SELECT 1 FROM comments WHERE parent_id = $1)"
+	if err := tx.QueryRowxContext(ctx, query, comment.ID).Scan(&hasReplies); err != nil {
+		return err
+	}
+
+	if hasReplies {
+		query := "UPDATE comments SET body = '', deleted_at = NOW() WHERE id = $1 RETURNING deleted_at"
+		if err := tx.QueryRowxContext(ctx, query, comment.ID).Scan(&comment.DeletedAt); err != nil {
+			return err
+		}
+
+		if err := announceComment(ctx, tx, conduit.CommentDeleted, comment.ArticleID, comment.ID); err != nil {
+			return err
+		}
+
+		if err := recordCommentChange(ctx, tx, conduit.CommentDeleted, comment); err != nil {
+			return err
+		}
+
+		// the placeholder no longer mentions anyone
+		return recordMentions(ctx, tx, comment.AuthorID, comment.ArticleID, comment.ID, "")
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM comments WHERE id = $1", comment.ID); err != nil {
+		return err
+	}
+
+	if err := announceComment(ctx, tx, conduit.CommentDeleted, comment.ArticleID, comment.ID); err != nil {
+		return err
+	}
+
+	if err := recordCommentChange(ctx, tx, conduit.CommentDeleted, comment); err != nil {
+		return err
+	}
+
+	parentID := comment.ParentID
+	for parentID != nil {
+		query := `
+		DELETE FROM comments
+		WHERE id = $1 AND deleted_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM comments WHERE parent_id = $1)
+		RETURNING parent_id, author_id
+		`
+
+		pruned := conduit.Comment{ID: *parentID, ArticleID: comment.ArticleID}
+		if err := tx.QueryRowxContext(ctx, query, *parentID).Scan(&pruned.ParentID, &pruned.AuthorID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return err
+		}
+
+		if err := announceComment(ctx, tx, conduit.CommentDeleted, comment.ArticleID, *parentID); err != nil {
+			return err
+		}
+
+		if err := recordCommentChange(ctx, tx, conduit.CommentDeleted, &pruned); err != nil {
+			return err
+		}
+
+		parentID = pruned.ParentID
+	}
+
+	return nil
+}
+
+func findComments(ctx context.Context, tx *sqlx.Tx, filter conduit.CommentFilter) ([]*conduit.Comment, error) {
+	where, args := []string{}, []interface{}{}
+	argPosition := 0
+
+	if v := filter.ID; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("id = $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.ArticleID; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("article_id = $%d", argPosition)), append(args, *v)
+	}
+
+	query := `
+	SELECT id, article_id, parent_id, depth, body, author_id, deleted_at, created_at
+	FROM comments` + formatWhereClause(where) + " ORDER BY created_at ASC, id ASC " + formatLimitOffset(filter.Limit, filter.Offset)
+
+	comments := make([]*conduit.Comment, 0)
+	if err := findMany(ctx, tx, &comments, query, args...); err != nil {
+		return nil, err
+	}
+
+	if err := attachCommentAssociations(ctx, tx, comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func attachCommentAssociations(ctx context.Context, tx *sqlx.Tx, comments []*conduit.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	authors := make(map[uint]*conduit.User)
+	ids := make([]uint, len(comments))
+
+	for i, c := range comments {
+		ids[i] = c.ID
+
+		author, ok := authors[c.AuthorID]
+		if !ok {
+			var err error
+			if author, err = findUserByID(ctx, tx, c.AuthorID); err != nil {
+				return fmt.Errorf("cannot find comment author: %w", err)
+			}
+			authors[c.AuthorID] = author
+		}
+
+		c.Author = author
+		c.Reactions = conduit.NewReactions()
+	}
+
+	query := "SELECT comment_id, kind, count FROM reaction_counts WHERE comment_id = ANY($1) AND count > 0"
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("cannot find comment reactions: %w", err)
+	}
+
+	defer rows.Close()
+
+	byID := make(map[uint]*conduit.Comment, len(comments))
+	for _, c := range comments {
+		byID[c.ID] = c
+	}
+
+	for rows.Next() {
+		var (
+			id    uint
+			kind  string
+			count int
+		)
+
+		if err := rows.Scan(&id, &kind, &count); err != nil {
+			return err
+		}
+
+		byID[id].Reactions.Counts[kind] = count
+	}
+
+	return rows.Err()
+}
@@ -9,6 +9,9 @@ import (
 
 type DB struct {
 	*sqlx.DB
+
+	// url is kept to open the dedicated connection LISTEN needs.
+	url string
 }
 
 func Open(url string) (*DB, error) {
@@ -22,5 +25,5 @@ func Open(url string) (*DB, error) {
 	}
 
 	log.Println("successfully connected to database")
-	return &DB{db}, nil
+	return &DB{db, url}, nil
 }
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// digestEarly is how long before its period is up a digest may be claimed.
+// Without it the hourly job would make every digest an hour later than the
+// one before.
+const digestEarly = time.Hour
+
+var _ conduit.DigestService = (*DigestService)(nil)
+
+type DigestService struct {
+	db *DB
+}
+
+func NewDigestService(db *DB) *DigestService {
+	return &DigestService{db}
+}
+
+func (ds *DigestService) DigestPreference(ctx context.Context, user *conduit.User) (*conduit.DigestPreference, error) {
+	tx, err := ds.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	pref := conduit.DigestPreference{}
+	if err := tx.GetContext(ctx, &pref, "SELECT * FROM digest_preferences WHERE user_id = $1", user.ID); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		pref = conduit.DigestPreference{UserID: user.ID, Frequency: conduit.DigestOff}
+	}
+
+	return &pref, tx.Commit()
+}
+
+func (ds *DigestService) SetDigestFrequency(ctx context.Context, user *conduit.User, frequency string) (*conduit.DigestPreference, error) {
+	tx, err := ds.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO digest_preferences (user_id, frequency) VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE SET frequency = EXCLUDED.frequency, updated_at = NOW()
+	RETURNING *
+	`
+
+	pref := conduit.DigestPreference{}
+	if err := tx.QueryRowxContext(ctx, query, user.ID, frequency).StructScan(&pref); err != nil {
+		return nil, err
+	}
+
+	return &pref, tx.Commit()
+}
+
+func (ds *DigestService) ClaimDueDigests(ctx context.Context, now time.Time, limit int) ([]*conduit.DigestPreference, error) {
+	tx, err := ds.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	UPDATE digest_preferences p SET last_sent_at = $1
+	FROM (
+		SELECT user_id, last_sent_at FROM digest_preferences
+		WHERE (frequency = 'daily' AND (last_sent_at IS NULL OR last_sent_at <= $2))
+		OR (frequency = 'weekly' AND (last_sent_at IS NULL OR last_sent_at <= $3))
+		ORDER BY user_id ASC
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	) due
+	WHERE p.user_id = due.user_id
+	RETURNING p.user_id, p.frequency, due.last_sent_at, p.updated_at
+	`
+
+	dailyBefore := now.Add(-conduit.DigestPeriod(conduit.DigestDaily) + digestEarly)
+	weeklyBefore := now.Add(-conduit.DigestPeriod(conduit.DigestWeekly) + digestEarly)
+
+	prefs := make([]*conduit.DigestPreference, 0)
+	if err := findMany(ctx, tx, &prefs, query, now, dailyBefore, weeklyBefore, limit); err != nil {
+		return nil, err
+	}
+
+	for _, p := range prefs {
+		if p.User, err = findUserByID(ctx, tx, p.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	return prefs, tx.Commit()
+}
@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// eventsChannel is the channel new events are announced on. NOTIFY is only
+// delivered once the transaction that stored the event commits.
+const eventsChannel = "conduit_events"
+
+// commentsChannel announces comments written and deleted on any article.
+const commentsChannel = "conduit_comments"
+
+var _ conduit.EventService = (*EventService)(nil)
+
+type EventService struct {
+	db *DB
+}
+
+func NewEventService(db *DB) *EventService {
+	return &EventService{db}
+}
+
+func (es *EventService) EventsSince(ctx context.Context, user *conduit.User, id uint64, limit int) ([]*conduit.Event, error) {
+	tx, err := es.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := "SELECT * FROM events WHERE user_id = $1 AND id > $2 ORDER BY id ASC " + formatLimitOffset(limit, 0)
+
+	events := make([]*conduit.Event, 0)
+	if err := findMany(ctx, tx, &events, query, user.ID, id); err != nil {
+		return nil, err
+	}
+
+	return events, tx.Commit()
+}
+
+// Listen keeps a dedicated connection listening for new events. The sink is
+// told about the events it may have missed each time the connection is
+// (re-)established, so clients can resume from the last event they saw.
+func (es *EventService) Listen(ctx context.Context, sink conduit.EventSink) error {
+	return es.listen(ctx, eventsChannel, sink.Missed, func(extra string) {
+		var n eventNotice
+		if err := json.Unmarshal([]byte(extra), &n); err != nil {
+			log.Printf("event listener: bad notice %q", extra)
+			return
+		}
+
+		if !sink.Wants(n.UserID) {
+			return
+		}
+
+		event := conduit.Event{}
+		if err := es.db.GetContext(ctx, &event, "SELECT * FROM events WHERE id = $1", n.ID); err != nil {
+			log.Printf("event listener: cannot load event %d: %v", n.ID, err)
+			return
+		}
+
+		sink.Publish(&event)
+	})
+}
+
+func (es *EventService) ListenComments(ctx context.Context, fn func(*conduit.CommentEvent)) error {
+	return es.listen(ctx, commentsChannel, nil, func(extra string) {
+		var n commentNotice
+		if err := json.Unmarshal([]byte(extra), &n); err != nil {
+			log.Printf("comment listener: bad notice %q", extra)
+			return
+		}
+
+		event := conduit.CommentEvent{Kind: n.Kind, ArticleID: n.ArticleID, CommentID: n.CommentID}
+
+		if n.Kind == conduit.CommentCreated {
+			tx, err := es.db.BeginTxx(ctx, nil)
+			if err != nil {
+				log.Printf("comment listener: %v", err)
+				return
+			}
+
+			defer tx.Rollback()
+
+			comments, err := findComments(ctx, tx, conduit.CommentFilter{ID: &n.CommentID})
+			if err != nil || len(comments) == 0 {
+				log.Printf("comment listener: cannot load comment %d: %v", n.CommentID, err)
+				return
+			}
+
+			event.Comment = comments[0]
+		}
+
+		fn(&event)
+	})
+}
+
+// listen calls handle with the payload of each notification on channel.
+// connected, when set, is called once listening and again after every
+// reconnect, since notifications sent meanwhile are lost.
+func (es *EventService) listen(ctx context.Context, channel string, connected func(), handle func(extra string)) error {
+	listener := pq.NewListener(es.db.url, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("%s listener: %v", channel, err)
+		}
+	})
+
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	if connected != nil {
+		connected()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-listener.Notify:
+			if n == nil {
+				// the connection was re-established
+				if connected != nil {
+					connected()
+				}
+				continue
+			}
+
+			handle(n.Extra)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+// publishEvent stores an event for the user and announces it.
+func publishEvent(ctx context.Context, tx *sqlx.Tx, userID uint, kind string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	WITH e AS (INSERT INTO events (user_id, kind, data) VALUES ($1, $2, $3) RETURNING id, user_id)
+	SELECT pg_notify('` + eventsChannel + `', json_build_object('id', id, 'userId', user_id)::text) FROM e
+	`
+
+	if _, err := tx.ExecContext(ctx, query, userID, kind, string(payload)); err != nil {
+		return fmt.Errorf("cannot publish %s event: %w", kind, err)
+	}
+
+	return nil
+}
+
+// publishEventToFollowers stores an event for every follower of the user.
+func publishEventToFollowers(ctx context.Context, tx *sqlx.Tx, userID uint, kind string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	WITH e AS (
+		INSERT INTO events (user_id, kind, data)
+		SELECT follower_id, $2::varchar, $3::jsonb FROM followings WHERE following_id = $1
+		RETURNING id, user_id
+	)
+	SELECT pg_notify('` + eventsChannel + `', json_build_object('id', id, 'userId', user_id)::text) FROM e
+	`
+
+	if _, err := tx.ExecContext(ctx, query, userID, kind, string(payload)); err != nil {
+		return fmt.Errorf("cannot publish %s event: %w", kind, err)
+	}
+
+	return nil
+}
+
+type articleEvent struct {
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+}
+
+// publishArticleEvent tells the author's followers, webhooks and the outbox
+// the article went public.
+func publishArticleEvent(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) error {
+	if err := recordArticleChange(ctx, tx, conduit.ArticlePublished, article); err != nil {
+		return err
+	}
+
+	data, err := newArticleEvent(ctx, tx, article)
+	if err != nil {
+		return err
+	}
+
+	if err := publishEventToFollowers(ctx, tx, article.AuthorID, conduit.EventArticle, data); err != nil {
+		return err
+	}
+
+	return enqueueWebhookDeliveries(ctx, tx, conduit.WebhookArticlePublished, article.AuthorID, data)
+}
+
+func newArticleEvent(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) (*articleEvent, error) {
+	author := article.Author
+	if author == nil {
+		var err error
+		if author, err = findUserByID(ctx, tx, article.AuthorID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &articleEvent{
+		Slug:        article.Slug,
+		Title:       article.Title,
+		Description: article.Description,
+		Author:      author.Username,
+	}, nil
+}
+
+type followEvent struct {
+	Follower string `json:"follower"`
+	User     string `json:"user"`
+}
+
+type commentEvent struct {
+	ArticleSlug string           `json:"articleSlug"`
+	Comment     *conduit.Comment `json:"comment"`
+}
+
+// eventNotice is the payload announced on eventsChannel. It carries the user
+// so instances without a stream of theirs open skip loading the event.
+type eventNotice struct {
+	ID     uint64 `json:"id"`
+	UserID uint   `json:"userId"`
+}
+
+// commentNotice is the payload announced on commentsChannel. It only holds
+// IDs since NOTIFY payloads are limited in size.
+type commentNotice struct {
+	Kind      string `json:"kind"`
+	ArticleID uint   `json:"articleId"`
+	CommentID uint   `json:"commentId"`
+}
+
+func announceComment(ctx context.Context, tx *sqlx.Tx, kind string, articleID, commentID uint) error {
+	payload, err := json.Marshal(commentNotice{kind, articleID, commentID})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", commentsChannel, string(payload)); err != nil {
+		return fmt.Errorf("cannot announce comment %d: %w", commentID, err)
+	}
+
+	return nil
+}
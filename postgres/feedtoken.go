@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.FeedTokenService = (*FeedTokenService)(nil)
+
+type FeedTokenService struct {
+	db *DB
+}
+
+func NewFeedTokenService(db *DB) *FeedTokenService {
+	return &FeedTokenService{db}
+}
+
+func (fs *FeedTokenService) FeedToken(ctx context.Context, user *conduit.User) (*conduit.FeedToken, error) {
+	tx, err := fs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	token := conduit.FeedToken{}
+	if err := tx.GetContext(ctx, &token, "SELECT created_at FROM feed_tokens WHERE user_id = $1", user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, conduit.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &token, tx.Commit()
+}
+
+func (fs *FeedTokenService) SetFeedToken(ctx context.Context, user *conduit.User, token string) (*conduit.FeedToken, error) {
+	tx, err := fs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO feed_tokens (user_id, token_hash) VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+	RETURNING created_at
+	`
+
+	ft := conduit.FeedToken{Token: token}
+	if err := tx.QueryRowxContext(ctx, query, user.ID, hashFeedToken(token)).Scan(&ft.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &ft, tx.Commit()
+}
+
+func (fs *FeedTokenService) RevokeFeedToken(ctx context.Context, user *conduit.User) error {
+	tx, err := fs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM feed_tokens WHERE user_id = $1", user.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return conduit.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (fs *FeedTokenService) UserByFeedToken(ctx context.Context, token string) (*conduit.User, error) {
+	tx, err := fs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var userID uint
+	if err := tx.GetContext(ctx, &userID, "SELECT user_id FROM feed_tokens WHERE token_hash = $1", hashFeedToken(token)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, conduit.ErrNotFound
+		}
+		return nil, err
+	}
+
+	user, err := findUserByID(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, tx.Commit()
+}
+
+// hashFeedToken is what is stored of a token, so that a leaked table does
+// not leak the feeds.
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
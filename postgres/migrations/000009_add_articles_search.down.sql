@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS articles_search_vector_idx;
+ALTER TABLE articles DROP COLUMN IF EXISTS search_vector;
+
+COMMIT;
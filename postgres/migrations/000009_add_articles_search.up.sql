@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+        setweight(to_tsvector('english', coalesce(body, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS articles_search_vector_idx ON articles USING GIN (search_vector);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tags_name_trgm_idx;
+DROP EXTENSION IF EXISTS pg_trgm;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS tags_name_trgm_idx ON tags USING GIN (lower(name::text) gin_trgm_ops);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS tag_audit_log;
+DROP TABLE IF EXISTS tag_aliases;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS tag_aliases (
+    alias citext primary key,
+    tag_id int not null,
+    created_at timestamptz not null default now(),
+    constraint fk_tag foreign key(tag_id) references tags(id) on delete cascade
+);
+
+CREATE TABLE IF NOT EXISTS tag_audit_log (
+    id serial primary key,
+    action varchar(32) not null,
+    tag_name citext not null,
+    target_name citext,
+    moderator_id int,
+    created_at timestamptz not null default now(),
+    constraint fk_moderator foreign key(moderator_id) references users(id) on delete set null
+);
+
+COMMIT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tag_followings;
\ No newline at end of file
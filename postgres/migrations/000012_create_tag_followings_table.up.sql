@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS tag_followings (
+    tag_id int not null,
+    follower_id int not null,
+    followed_on timestamptz not null default now(),
+    primary key (tag_id, follower_id),
+    constraint fk_tag foreign key(tag_id) references tags(id) on delete cascade,
+    constraint fk_follower foreign key(follower_id) references users(id) on delete cascade
+);
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+UPDATE articles SET published_at = created_at WHERE status = 'published' AND published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS articles_scheduled_idx ON articles (publish_at) WHERE status = 'scheduled';
+
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id serial primary key,
+    article_id int not null,
+    revision int not null,
+    title text not null,
+    description text,
+    body text not null,
+    tags text[] not null default '{}',
+    editor_id int,
+    created_at timestamptz not null default now(),
+    unique (article_id, revision),
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade,
+    constraint fk_editor foreign key(editor_id) references users(id) on delete set null
+);
+
+INSERT INTO article_revisions (article_id, revision, title, description, body, tags, editor_id, created_at)
+SELECT a.id, 1, a.title, a.description, a.body,
+    COALESCE((SELECT array_agg(t.name::text ORDER BY t.name) FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE at.article_id = a.id), '{}'),
+    a.author_id, a.updated_at
+FROM articles a;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS article_slugs (
+    slug VARCHAR(255) primary key,
+    article_id int not null,
+    created_at timestamptz not null default now(),
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade
+);
+
+COMMIT;
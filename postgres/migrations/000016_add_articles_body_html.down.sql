@@ -0,0 +1 @@
+ALTER TABLE articles DROP COLUMN IF EXISTS body_html;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS body_html TEXT NOT NULL DEFAULT '';
\ No newline at end of file
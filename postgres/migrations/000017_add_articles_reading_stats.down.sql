@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS articles_reading_time_idx;
+ALTER TABLE articles DROP COLUMN IF EXISTS reading_time_minutes;
+ALTER TABLE articles DROP COLUMN IF EXISTS word_count;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS word_count INT NOT NULL DEFAULT 0;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS reading_time_minutes INT NOT NULL DEFAULT 0;
+
+-- an approximation on the raw markdown; rows are recomputed exactly on their next update
+UPDATE articles SET word_count = COALESCE(array_length(regexp_split_to_array(btrim(body), '\s+'), 1), 0)
+WHERE btrim(body) <> '';
+UPDATE articles SET reading_time_minutes = GREATEST(1, CEIL(word_count / 200.0))
+WHERE word_count > 0;
+
+CREATE INDEX IF NOT EXISTS articles_reading_time_idx ON articles (reading_time_minutes);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS series_articles;
+DROP TABLE IF EXISTS series;
+
+COMMIT;
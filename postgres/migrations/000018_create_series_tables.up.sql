@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS series (
+    id serial primary key,
+    title text not null,
+    slug varchar(255) not null unique,
+    description text not null default '',
+    owner_id int not null,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    constraint fk_owner foreign key(owner_id) references users(id) on delete cascade
+);
+
+CREATE TABLE IF NOT EXISTS series_articles (
+    series_id int not null,
+    article_id int not null unique,
+    position int not null,
+    primary key (series_id, article_id),
+    unique (series_id, position) deferrable initially deferred,
+    constraint fk_series foreign key(series_id) references series(id) on delete cascade,
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade
+);
+
+COMMIT;
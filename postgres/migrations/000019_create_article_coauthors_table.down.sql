@@ -0,0 +1 @@
+DROP TABLE IF EXISTS article_coauthors;
\ No newline at end of file
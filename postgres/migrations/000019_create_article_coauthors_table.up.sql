@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS article_coauthors (
+    article_id int not null,
+    user_id int not null,
+    position int not null default 0,
+    status varchar(16) not null default 'invited',
+    invited_by int not null,
+    invited_at timestamptz not null default now(),
+    accepted_at timestamptz,
+    primary key (article_id, user_id),
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade,
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade,
+    constraint fk_invited_by foreign key(invited_by) references users(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS article_coauthors_user_idx ON article_coauthors (user_id, status);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    user_id int not null,
+    article_id int not null,
+    folder varchar(64) not null default '',
+    created_at timestamptz not null default now(),
+    primary key (user_id, article_id),
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade,
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS bookmarks_user_folder_idx ON bookmarks (user_id, folder, created_at DESC);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS reactions_count ON reactions;
+DROP FUNCTION IF EXISTS count_reactions();
+DROP TABLE IF EXISTS reaction_counts;
+DROP TABLE IF EXISTS reactions;
+
+COMMIT;
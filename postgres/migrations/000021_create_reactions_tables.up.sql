@@ -0,0 +1,52 @@
+BEGIN;
+
+-- comment_id is null for reactions on the article itself
+CREATE TABLE IF NOT EXISTS reactions (
+    article_id int not null,
+    comment_id int,
+    user_id int not null,
+    kind varchar(16) not null,
+    created_at timestamptz not null default now(),
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade,
+    constraint fk_comment foreign key(comment_id) references comments(id) on delete cascade,
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reactions_target_user_kind_key ON reactions (article_id, COALESCE(comment_id, 0), user_id, kind);
+CREATE INDEX IF NOT EXISTS reactions_user_idx ON reactions (user_id);
+
+-- reaction_counts follows reactions through a trigger rather than the
+-- reaction service, so reactions removed by cascades, e.g. when their user is
+-- deleted, are counted out too
+CREATE TABLE IF NOT EXISTS reaction_counts (
+    article_id int not null,
+    comment_id int,
+    kind varchar(16) not null,
+    count int not null default 0,
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade,
+    constraint fk_comment foreign key(comment_id) references comments(id) on delete cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reaction_counts_target_kind_key ON reaction_counts (article_id, COALESCE(comment_id, 0), kind);
+
+CREATE OR REPLACE FUNCTION count_reactions() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO reaction_counts (article_id, comment_id, kind, count)
+        VALUES (NEW.article_id, NEW.comment_id, NEW.kind, 1)
+        ON CONFLICT (article_id, COALESCE(comment_id, 0), kind) DO UPDATE SET count = reaction_counts.count + 1;
+
+        RETURN NEW;
+    END IF;
+
+    UPDATE reaction_counts SET count = count - 1
+    WHERE article_id = OLD.article_id AND COALESCE(comment_id, 0) = COALESCE(OLD.comment_id, 0) AND kind = OLD.kind;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reactions_count AFTER INSERT OR DELETE ON reactions
+FOR EACH ROW EXECUTE PROCEDURE count_reactions();
+
+COMMIT;
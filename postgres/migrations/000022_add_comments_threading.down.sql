@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS comments_parent_idx;
+DROP INDEX IF EXISTS comments_article_idx;
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS depth;
+ALTER TABLE comments DROP COLUMN IF EXISTS parent_id;
+
+COMMIT;
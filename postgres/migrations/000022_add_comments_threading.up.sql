@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id int REFERENCES comments(id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth int NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS comments_article_idx ON comments (article_id, created_at);
+CREATE INDEX IF NOT EXISTS comments_parent_idx ON comments (parent_id);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS mentions;
+DROP TABLE IF EXISTS user_blocks;
+
+COMMIT;
@@ -0,0 +1,46 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id int not null,
+    blocked_id int not null,
+    created_at timestamptz not null default now(),
+    primary key (blocker_id, blocked_id),
+    constraint fk_blocker foreign key(blocker_id) references users(id) on delete cascade,
+    constraint fk_blocked foreign key(blocked_id) references users(id) on delete cascade
+);
+
+-- comment_id is null for mentions in the article body
+CREATE TABLE IF NOT EXISTS mentions (
+    user_id int not null,
+    article_id int not null,
+    comment_id int,
+    author_id int not null,
+    notified_at timestamptz,
+    created_at timestamptz not null default now(),
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade,
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade,
+    constraint fk_comment foreign key(comment_id) references comments(id) on delete cascade,
+    constraint fk_author foreign key(author_id) references users(id) on delete cascade
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS mentions_user_target_key ON mentions (user_id, article_id, COALESCE(comment_id, 0));
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id serial primary key,
+    user_id int not null,
+    kind varchar(16) not null,
+    actor_id int not null,
+    article_id int,
+    comment_id int,
+    read_at timestamptz,
+    created_at timestamptz not null default now(),
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade,
+    constraint fk_actor foreign key(actor_id) references users(id) on delete cascade,
+    constraint fk_article foreign key(article_id) references articles(id) on delete cascade,
+    constraint fk_comment foreign key(comment_id) references comments(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx ON notifications (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- events holds what is pushed to each user's live stream, so that clients
+-- can resume after reconnecting
+CREATE TABLE IF NOT EXISTS events (
+    id bigserial primary key,
+    user_id int not null,
+    kind varchar(32) not null,
+    data jsonb not null,
+    created_at timestamptz not null default now(),
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS events_user_idx ON events (user_id, id);
+
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id serial primary key,
+    owner_id int not null,
+    scope varchar(8) not null default 'user',
+    url text not null,
+    secret text not null,
+    events text[] not null,
+    active boolean not null default true,
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now(),
+    constraint fk_owner foreign key(owner_id) references users(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_owner_idx ON webhooks (owner_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial primary key,
+    webhook_id int not null,
+    event varchar(32) not null,
+    payload jsonb not null,
+    status varchar(16) not null default 'pending',
+    attempts int not null default 0,
+    next_attempt_at timestamptz default now(),
+    last_status_code int,
+    last_error text,
+    created_at timestamptz not null default now(),
+    delivered_at timestamptz,
+    constraint fk_webhook foreign key(webhook_id) references webhooks(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id DESC);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id bigserial primary key,
+    aggregate_type varchar(16) not null,
+    aggregate_id int not null,
+    kind varchar(32) not null,
+    payload jsonb not null,
+    created_at timestamptz not null default now(),
+    published_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_published_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+
+COMMIT;
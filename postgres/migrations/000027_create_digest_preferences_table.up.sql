@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS digest_preferences (
+    user_id int primary key,
+    frequency varchar(8) not null default 'off',
+    last_sent_at timestamptz,
+    updated_at timestamptz not null default now(),
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade
+);
+
+CREATE INDEX IF NOT EXISTS digest_preferences_due_idx ON digest_preferences (last_sent_at) WHERE frequency <> 'off';
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS feed_tokens (
+    user_id int primary key,
+    token_hash char(64) not null unique,
+    created_at timestamptz not null default now(),
+    constraint fk_user foreign key(user_id) references users(id) on delete cascade
+);
+
+COMMIT;
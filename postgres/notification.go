@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.NotificationService = (*NotificationService)(nil)
+
+type NotificationService struct {
+	db *DB
+}
+
+func NewNotificationService(db *DB) *NotificationService {
+	return &NotificationService{db}
+}
+
+func (ns *NotificationService) Notifications(ctx context.Context, user *conduit.User, filter conduit.NotificationFilter) ([]*conduit.Notification, error) {
+	tx, err := ns.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	where, args := []string{"n.user_id = $1"}, []interface{}{user.ID}
+
+	if filter.UnreadOnly {
+		where = append(where, "n.read_at IS NULL")
+	}
+
+	notifications, err := findNotifications(ctx, tx, where, args, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, tx.Commit()
+}
+
+func (ns *NotificationService) UnreadNotificationCount(ctx context.Context, user *conduit.User) (int, error) {
+	tx, err := ns.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var count int
+
+	query := "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL"
+	if err := tx.QueryRowxContext(ctx, query, user.ID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
+
+func (ns *NotificationService) MarkNotificationRead(ctx context.Context, user *conduit.User, id uint) error {
+	tx, err := ns.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2"
+
+	res, err := tx.ExecContext(ctx, query, id, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return conduit.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (ns *NotificationService) MarkAllNotificationsRead(ctx context.Context, user *conduit.User) error {
+	tx, err := ns.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL"
+	if _, err := tx.ExecContext(ctx, query, user.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// notify stores n unless its actor is the recipient or is blocked by them.
+func notify(ctx context.Context, tx *sqlx.Tx, n *conduit.Notification) error {
+	if n.UserID == n.ActorID {
+		return nil
+	}
+
+	query := `
+	INSERT INTO notifications (user_id, kind, actor_id, article_id, comment_id)
+	SELECT $1::int, $2::varchar, $3::int, $4::int, $5::int
+	WHERE NOT EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $1::int AND blocked_id = $3::int)
+	RETURNING id
+	`
+
+	ids := make([]uint, 0, 1)
+	if err := tx.SelectContext(ctx, &ids, query, n.UserID, n.Kind, n.ActorID, n.ArticleID, n.CommentID); err != nil {
+		return fmt.Errorf("cannot notify user %d: %w", n.UserID, err)
+	}
+
+	return publishNotifications(ctx, tx, ids)
+}
+
+// recordMentions stores who text mentions, replacing the mentions previously
+// recorded for the same article body or comment. Unknown usernames are
+// ignored. Nobody is notified until notifyMentions runs.
+func recordMentions(ctx context.Context, tx *sqlx.Tx, authorID, articleID, commentID uint, text string) error {
+	usernames := conduit.ParseMentions(text)
+
+	query := `
+	DELETE FROM mentions
+	WHERE article_id = $1 AND COALESCE(comment_id, 0) = $2
+	AND user_id NOT IN (SELECT id FROM users WHERE username = ANY($3))
+	`
+
+	if _, err := tx.ExecContext(ctx, query, articleID, commentID, pq.Array(usernames)); err != nil {
+		return err
+	}
+
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	query = `
+	INSERT INTO mentions (user_id, article_id, comment_id, author_id)
+	SELECT id, $1::int, NULLIF($2::int, 0), $3::int FROM users WHERE username = ANY($4)
+	ON CONFLICT (user_id, article_id, COALESCE(comment_id, 0)) DO NOTHING
+	`
+
+	_, err := tx.ExecContext(ctx, query, articleID, commentID, authorID, pq.Array(usernames))
+	return err
+}
+
+// notifyMentions notifies everyone mentioned in the article or its comments
+// who was not notified yet, once the article is published.
+func notifyMentions(ctx context.Context, tx *sqlx.Tx, articleID uint) error {
+	query := `
+	WITH pending AS (
+		UPDATE mentions m SET notified_at = NOW()
+		FROM articles a
+		WHERE m.article_id = $1 AND a.id = m.article_id AND a.status = 'published' AND m.notified_at IS NULL
+		RETURNING m.user_id, m.author_id, m.article_id, m.comment_id
+	)
+	INSERT INTO notifications (user_id, kind, actor_id, article_id, comment_id)
+	SELECT p.user_id, 'mention', p.author_id, p.article_id, p.comment_id FROM pending p
+	WHERE p.user_id <> p.author_id
+	AND NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blocker_id = p.user_id AND b.blocked_id = p.author_id)
+	RETURNING id
+	`
+
+	ids := make([]uint, 0)
+	if err := tx.SelectContext(ctx, &ids, query, articleID); err != nil {
+		return fmt.Errorf("cannot notify mentions in article %d: %w", articleID, err)
+	}
+
+	return publishNotifications(ctx, tx, ids)
+}
+
+// publishNotifications pushes the new notifications to their recipients'
+// live streams.
+func publishNotifications(ctx context.Context, tx *sqlx.Tx, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	notifications, err := findNotifications(ctx, tx, []string{"n.id = ANY($1)"}, []interface{}{pq.Array(ids)}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range notifications {
+		if err := publishEvent(ctx, tx, n.UserID, conduit.EventNotification, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findNotifications(ctx context.Context, tx *sqlx.Tx, where []string, args []interface{}, limit, offset int) ([]*conduit.Notification, error) {
+	query := `
+	SELECT n.id, n.kind, n.user_id, n.actor_id, u.username AS actor_username, COALESCE(u.image, '') AS actor_image,
+		n.article_id, a.slug AS article_slug, n.comment_id, n.read_at, n.created_at
+	FROM notifications n
+	JOIN users u ON u.id = n.actor_id
+	LEFT JOIN articles a ON a.id = n.article_id` + formatWhereClause(where) + `
+	ORDER BY n.created_at DESC, n.id DESC ` + formatLimitOffset(limit, offset)
+
+	notifications := make([]*conduit.Notification, 0)
+	if err := findMany(ctx, tx, &notifications, query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, n := range notifications {
+		n.SetActorProfile()
+	}
+
+	return notifications, nil
+}
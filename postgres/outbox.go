@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// outboxLockKey is the advisory lock held while relaying, so that a single
+// relay publishes at a time and events of an aggregate stay in order across
+// server instances.
+const outboxLockKey = 0x6f7574626f78
+
+var _ conduit.OutboxService = (*OutboxService)(nil)
+
+type OutboxService struct {
+	db *DB
+}
+
+func NewOutboxService(db *DB) *OutboxService {
+	return &OutboxService{db}
+}
+
+// RelayDomainEvents relays inside the transaction that marks the events, so
+// an event whose mark is lost to a failed commit is published again.
+func (ob *OutboxService) RelayDomainEvents(ctx context.Context, relay func(conduit.PendingDomainEvents) ([]uint64, error)) (int, error) {
+	tx, err := ob.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowxContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", outboxLockKey).Scan(&locked); err != nil {
+		return 0, err
+	}
+
+	// another instance is relaying
+	if !locked {
+		return 0, nil
+	}
+
+	published, err := relay(func(afterID uint64, skip []string, limit int) ([]*conduit.DomainEvent, error) {
+		query := `
+		SELECT * FROM outbox
+		WHERE published_at IS NULL AND id > $1 AND aggregate_type || '/' || aggregate_id <> ALL($2)
+		ORDER BY id ASC ` + formatLimitOffset(limit, 0)
+
+		events := make([]*conduit.DomainEvent, 0)
+		if err := findMany(ctx, tx, &events, query, afterID, pq.Array(skip)); err != nil {
+			return nil, err
+		}
+
+		return events, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(published) > 0 {
+		ids := make([]int64, 0, len(published))
+		for _, id := range published {
+			ids = append(ids, int64(id))
+		}
+
+		query := "UPDATE outbox SET published_at = NOW() WHERE id = ANY($1)"
+		if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(published), nil
+}
+
+func (ob *OutboxService) DeletePublishedDomainEvents(ctx context.Context, before time.Time) (int, error) {
+	res, err := ob.db.ExecContext(ctx, "DELETE FROM outbox WHERE published_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// recordDomainEvent writes an event to the outbox. It is only relayed once
+// the transaction commits.
+func recordDomainEvent(ctx context.Context, tx *sqlx.Tx, aggregateType string, aggregateID uint, kind string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO outbox (aggregate_type, aggregate_id, kind, payload) VALUES ($1, $2, $3, $4)"
+	if _, err := tx.ExecContext(ctx, query, aggregateType, aggregateID, kind, string(payload)); err != nil {
+		return fmt.Errorf("cannot record %s event: %w", kind, err)
+	}
+
+	return nil
+}
+
+type articleChange struct {
+	ID       uint   `json:"id"`
+	Slug     string `json:"slug"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	AuthorID uint   `json:"authorId"`
+}
+
+func recordArticleChange(ctx context.Context, tx *sqlx.Tx, kind string, article *conduit.Article) error {
+	data := articleChange{article.ID, article.Slug, article.Title, article.Status, article.AuthorID}
+	return recordDomainEvent(ctx, tx, conduit.AggregateArticle, article.ID, kind, data)
+}
+
+type userChange struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+func recordUserChange(ctx context.Context, tx *sqlx.Tx, kind string, user *conduit.User) error {
+	data := userChange{user.ID, user.Username}
+	return recordDomainEvent(ctx, tx, conduit.AggregateUser, user.ID, kind, data)
+}
+
+type commentChange struct {
+	ID        uint  `json:"id"`
+	ArticleID uint  `json:"articleId"`
+	ParentID  *uint `json:"parentId"`
+	AuthorID  uint  `json:"authorId"`
+}
+
+func recordCommentChange(ctx context.Context, tx *sqlx.Tx, kind string, comment *conduit.Comment) error {
+	data := commentChange{comment.ID, comment.ArticleID, comment.ParentID, comment.AuthorID}
+	return recordDomainEvent(ctx, tx, conduit.AggregateComment, comment.ID, kind, data)
+}
+
+// followChange is recorded against the followed user.
+type followChange struct {
+	FollowerID uint `json:"followerId"`
+	UserID     uint `json:"userId"`
+}
+
+// favoriteChange is recorded against the article.
+type favoriteChange struct {
+	ArticleID uint `json:"articleId"`
+	UserID    uint `json:"userId"`
+}
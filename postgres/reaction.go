@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.ReactionService = (*ReactionService)(nil)
+
+type ReactionService struct {
+	db *DB
+}
+
+func NewReactionService(db *DB) *ReactionService {
+	return &ReactionService{db}
+}
+
+func (rs *ReactionService) AddReaction(ctx context.Context, target conduit.ReactionTarget, user *conduit.User, kind string) (*conduit.Reactions, error) {
+	tx, err := rs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO reactions (article_id, comment_id, user_id, kind) VALUES ($1, NULLIF($2, 0), $3, $4)
+	ON CONFLICT (article_id, COALESCE(comment_id, 0), user_id, kind) DO NOTHING
+	`
+
+	if _, err := tx.ExecContext(ctx, query, target.ArticleID, target.CommentID, user.ID, kind); err != nil {
+		return nil, err
+	}
+
+	reactions, err := findReactionCounts(ctx, tx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return reactions, tx.Commit()
+}
+
+func (rs *ReactionService) RemoveReaction(ctx context.Context, target conduit.ReactionTarget, user *conduit.User, kind string) (*conduit.Reactions, error) {
+	tx, err := rs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	DELETE FROM reactions
+	WHERE article_id = $1 AND COALESCE(comment_id, 0) = $2 AND user_id = $3 AND kind = $4
+	`
+
+	res, err := tx.ExecContext(ctx, query, target.ArticleID, target.CommentID, user.ID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, conduit.ErrNotFound
+	}
+
+	reactions, err := findReactionCounts(ctx, tx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return reactions, tx.Commit()
+}
+
+func (rs *ReactionService) Reactions(ctx context.Context, target conduit.ReactionTarget, filter conduit.ReactionFilter) ([]*conduit.Reaction, error) {
+	tx, err := rs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	where := []string{"r.article_id = $1", "COALESCE(r.comment_id, 0) = $2"}
+	args := []interface{}{target.ArticleID, target.CommentID}
+	argPosition := 2
+
+	if v := filter.Kind; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("r.kind = $%d", argPosition)), append(args, *v)
+	}
+
+	query := `
+	SELECT r.kind, r.user_id, u.username, u.image, r.created_at
+	FROM reactions r JOIN users u ON u.id = r.user_id` + formatWhereClause(where) + `
+	ORDER BY r.created_at DESC ` + formatLimitOffset(filter.Limit, filter.Offset)
+
+	reactions := make([]*conduit.Reaction, 0)
+	if err := findMany(ctx, tx, &reactions, query, args...); err != nil {
+		return nil, err
+	}
+
+	return reactions, tx.Commit()
+}
+
+func (rs *ReactionService) ViewerReactions(ctx context.Context, user *conduit.User, targets ...conduit.ReactionTarget) (map[conduit.ReactionTarget][]string, error) {
+	mine := make(map[conduit.ReactionTarget][]string, len(targets))
+	if len(targets) == 0 {
+		return mine, nil
+	}
+
+	tx, err := rs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	articleIDs := make([]uint, 0, len(targets))
+	for _, t := range targets {
+		mine[t] = []string{}
+		articleIDs = append(articleIDs, t.ArticleID)
+	}
+
+	query := `
+	SELECT article_id, COALESCE(comment_id, 0), kind FROM reactions
+	WHERE user_id = $1 AND article_id = ANY($2)
+	ORDER BY created_at ASC
+	`
+
+	rows, err := tx.QueryContext(ctx, query, user.ID, pq.Array(articleIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			t    conduit.ReactionTarget
+			kind string
+		)
+
+		if err := rows.Scan(&t.ArticleID, &t.CommentID, &kind); err != nil {
+			return nil, err
+		}
+
+		if kinds, ok := mine[t]; ok {
+			mine[t] = append(kinds, kind)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mine, tx.Commit()
+}
+
+func findReactionCounts(ctx context.Context, tx *sqlx.Tx, target conduit.ReactionTarget) (*conduit.Reactions, error) {
+	query := `
+	SELECT kind, count FROM reaction_counts
+	WHERE article_id = $1 AND COALESCE(comment_id, 0) = $2 AND count > 0
+	`
+
+	rows, err := tx.QueryContext(ctx, query, target.ArticleID, target.CommentID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reactions := conduit.NewReactions()
+	for rows.Next() {
+		var (
+			kind  string
+			count int
+		)
+
+		if err := rows.Scan(&kind, &count); err != nil {
+			return nil, err
+		}
+
+		reactions.Counts[kind] = count
+	}
+
+	return reactions, rows.Err()
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// revisionRow scans an article_revisions row, whose tags are a text array.
+type revisionRow struct {
+	conduit.ArticleRevision
+	Tags pq.StringArray
+}
+
+func (as *ArticleService) ArticleRevisions(ctx context.Context, article *conduit.Article) ([]*conduit.ArticleRevision, error) {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	revisions, err := findArticleRevisions(ctx, tx, article, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, tx.Commit()
+}
+
+func (as *ArticleService) ArticleRevision(ctx context.Context, article *conduit.Article, number int) (*conduit.ArticleRevision, error) {
+	tx, err := as.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	revisions, err := findArticleRevisions(ctx, tx, article, &number)
+	if err != nil {
+		return nil, err
+	} else if len(revisions) == 0 {
+		return nil, conduit.ErrNotFound
+	}
+
+	return revisions[0], tx.Commit()
+}
+
+// recordArticleRevision snapshots the article as its next revision. Callers
+// hold the article row lock through the update, so numbers cannot collide.
+func recordArticleRevision(ctx context.Context, tx *sqlx.Tx, article *conduit.Article, editor *conduit.User) error {
+	tags := make([]string, len(article.Tags))
+	for i, t := range article.Tags {
+		tags[i] = t.Name
+	}
+	sort.Strings(tags)
+
+	var editorID *uint
+	if editor != nil {
+		editorID = &editor.ID
+	}
+
+	query := `
+	INSERT INTO article_revisions (article_id, revision, title, description, body, tags, editor_id)
+	VALUES ($1, (SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revisions WHERE article_id = $1), $2, $3, $4, $5, $6)
+	`
+
+	args := []interface{}{
+		article.ID,
+		article.Title,
+		article.Description,
+		article.Body,
+		pq.StringArray(tags),
+		editorID,
+	}
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func findArticleRevisions(ctx context.Context, tx *sqlx.Tx, article *conduit.Article, number *int) ([]*conduit.ArticleRevision, error) {
+	query := `
+	SELECT r.id, r.article_id, r.revision, r.title, COALESCE(r.description, '') AS description, r.body, r.tags,
+		r.editor_id, COALESCE(u.username, '') AS editor_username, r.created_at
+	FROM article_revisions r LEFT JOIN users u ON u.id = r.editor_id
+	WHERE r.article_id = $1 AND ($2::int IS NULL OR r.revision = $2)
+	ORDER BY r.revision DESC
+	`
+
+	rows := make([]*revisionRow, 0)
+	if err := findMany(ctx, tx, &rows, query, article.ID, number); err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*conduit.ArticleRevision, len(rows))
+	for i, row := range rows {
+		row.ArticleRevision.Tags = row.Tags
+		revisions[i] = &row.ArticleRevision
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.SearchIndex = (*SearchIndex)(nil)
+
+// SearchIndex answers search queries from the articles.search_vector column.
+// The column is generated by postgres, so Index and Delete have nothing to do.
+type SearchIndex struct {
+	db *DB
+}
+
+func NewSearchIndex(db *DB) *SearchIndex {
+	return &SearchIndex{db}
+}
+
+func (si *SearchIndex) Index(_ context.Context, _ *conduit.Article) error {
+	return nil
+}
+
+func (si *SearchIndex) Delete(_ context.Context, _ *conduit.Article) error {
+	return nil
+}
+
+func (si *SearchIndex) Query(ctx context.Context, q conduit.SearchQuery) (*conduit.SearchResult, error) {
+	tx, err := si.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := searchArticles(ctx, tx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, tx.Commit()
+}
+
+func searchArticles(ctx context.Context, tx *sqlx.Tx, q conduit.SearchQuery) (*conduit.SearchResult, error) {
+	where, args := []string{"status = 'published'", "search_vector @@ websearch_to_tsquery('english', $1)"}, []interface{}{q.Text}
+	argPosition := 1
+
+	if v := q.Tag; v != nil {
+		argPosition++
+		clause := `id IN (select article_id from article_tags where tag_id in (
+			   select id from tags where name = $%d)
+		    )`
+		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
+	}
+
+	if v := q.AuthorUsername; v != nil {
+		argPosition++
+		clause := "author_id = (select id from users where username = $%d)"
+		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
+	}
+
+	matches := "SELECT id, author_id from articles" + formatWhereClause(where)
+
+	query := `
+	SELECT id AS article_id, slug,
+		ts_rank(search_vector, websearch_to_tsquery('english', $1)) AS score,
+		ts_headline('english', body, websearch_to_tsquery('english', $1), '` + headlineOptions + `') AS highlight
+	FROM articles` + formatWhereClause(where) + " ORDER BY score DESC, created_at DESC " + formatLimitOffset(q.Limit, q.Offset)
+
+	hits := make([]*conduit.SearchHit, 0)
+	if err := tx.SelectContext(ctx, &hits, query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, h := range hits {
+		h.Highlight = headlineHTML(h.Highlight)
+	}
+
+	result := &conduit.SearchResult{Hits: hits, Facets: map[string][]conduit.FacetCount{}}
+
+	if err := tx.GetContext(ctx, &result.Total, "SELECT count(*) FROM ("+matches+") AS m", args...); err != nil {
+		return nil, err
+	}
+
+	facets := map[string]string{
+		"tag": `
+		SELECT t.name AS value, count(*) AS count
+		FROM (` + matches + `) AS m
+		JOIN article_tags at ON at.article_id = m.id
+		JOIN tags t ON t.id = at.tag_id
+		GROUP BY t.name ORDER BY count DESC, t.name`,
+		"author": `
+		SELECT u.username AS value, count(*) AS count
+		FROM (` + matches + `) AS m
+		JOIN users u ON u.id = m.author_id
+		GROUP BY u.username ORDER BY count DESC, u.username`,
+	}
+
+	for name, query := range facets {
+		counts := make([]conduit.FacetCount, 0)
+		if err := tx.SelectContext(ctx, &counts, query, args...); err != nil {
+			return nil, err
+		}
+		result.Facets[name] = counts
+	}
+
+	return result, nil
+}
+
+// ts_headline marks the matches with sentinels rather than tags, so that the
+// raw body around them can be escaped before they are wrapped in <b>.
+const (
+	headlineStart   = "[[hl[["
+	headlineStop    = "]]hl]]"
+	headlineOptions = "MaxFragments=2, MaxWords=30, MinWords=10, StartSel=" + headlineStart + ", StopSel=" + headlineStop
+)
+
+func headlineHTML(headline string) string {
+	return strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>").Replace(html.EscapeString(headline))
+}
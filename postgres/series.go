@@ -0,0 +1,329 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gosimple/slug"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.SeriesService = (*SeriesService)(nil)
+
+type SeriesService struct {
+	db *DB
+}
+
+func NewSeriesService(db *DB) *SeriesService {
+	return &SeriesService{db}
+}
+
+func (ss *SeriesService) CreateSeries(ctx context.Context, series *conduit.Series) error {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := createSeries(ctx, tx, series); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss *SeriesService) SeriesBySlug(ctx context.Context, slug string) (*conduit.Series, error) {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	series, err := findSeries(ctx, tx, conduit.SeriesFilter{Slug: &slug})
+	if err != nil {
+		return nil, err
+	} else if len(series) == 0 {
+		return nil, conduit.ErrNotFound
+	}
+
+	return series[0], tx.Commit()
+}
+
+func (ss *SeriesService) Series(ctx context.Context, filter conduit.SeriesFilter) ([]*conduit.Series, error) {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	series, err := findSeries(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return series, tx.Commit()
+}
+
+func (ss *SeriesService) UpdateSeries(ctx context.Context, series *conduit.Series, patch conduit.SeriesPatch) error {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := updateSeries(ctx, tx, series, patch); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss *SeriesService) DeleteSeries(ctx context.Context, series *conduit.Series) error {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM series WHERE id = $1", series.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss *SeriesService) SetSeriesArticles(ctx context.Context, series *conduit.Series, articleIDs []uint) error {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := setSeriesArticles(ctx, tx, series, articleIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func createSeries(ctx context.Context, tx *sqlx.Tx, series *conduit.Series) error {
+	s, err := uniqueSeriesSlug(ctx, tx, series.Title, 0)
+	if err != nil {
+		return err
+	}
+	series.Slug = s
+
+	query := `
+	INSERT INTO series (title, slug, description, owner_id)
+	VALUES ($1, $2, $3, $4) RETURNING id, owner_id, created_at, updated_at
+	`
+
+	args := []interface{}{series.Title, series.Slug, series.Description, series.Owner.ID}
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&series.ID, &series.OwnerID, &series.CreatedAt, &series.UpdatedAt); err != nil {
+		return err
+	}
+
+	series.Articles = make([]*conduit.SeriesEntry, 0)
+
+	return nil
+}
+
+func updateSeries(ctx context.Context, tx *sqlx.Tx, series *conduit.Series, patch conduit.SeriesPatch) error {
+	if v := patch.Title; v != nil && *v != series.Title {
+		s, err := uniqueSeriesSlug(ctx, tx, *v, series.ID)
+		if err != nil {
+			return err
+		}
+		series.Title, series.Slug = *v, s
+	}
+
+	if v := patch.Description; v != nil {
+		series.Description = *v
+	}
+
+	query := `
+	UPDATE series SET title = $1, slug = $2, description = $3, updated_at = NOW()
+	WHERE id = $4
+	RETURNING updated_at`
+
+	args := []interface{}{series.Title, series.Slug, series.Description, series.ID}
+
+	return tx.QueryRowxContext(ctx, query, args...).Scan(&series.UpdatedAt)
+}
+
+func setSeriesArticles(ctx context.Context, tx *sqlx.Tx, series *conduit.Series, articleIDs []uint) error {
+	var inOtherSeries bool
+	query := "SELECT EXISTS (SELECT 1 FROM series_articles WHERE article_id = ANY($1) AND series_id <> $2)"
+	if err := tx.GetContext(ctx, &inOtherSeries, query, pq.Array(articleIDs), series.ID); err != nil {
+		return err
+	}
+
+	if inOtherSeries {
+		return conduit.ErrArticleInSeries
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM series_articles WHERE series_id = $1", series.ID); err != nil {
+		return err
+	}
+
+	for i, id := range articleIDs {
+		query := "INSERT INTO series_articles (series_id, article_id, position) VALUES ($1, $2, $3)"
+		if _, err := tx.ExecContext(ctx, query, series.ID, id, i+1); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE series SET updated_at = NOW() WHERE id = $1", series.ID); err != nil {
+		return err
+	}
+
+	entries, err := findSeriesEntries(ctx, tx, series)
+	if err != nil {
+		return err
+	}
+
+	series.Articles = entries
+
+	return nil
+}
+
+func findSeries(ctx context.Context, tx *sqlx.Tx, filter conduit.SeriesFilter) ([]*conduit.Series, error) {
+	where, args := []string{}, []interface{}{}
+	argPosition := 0
+
+	if v := filter.ID; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("id = $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.Slug; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("slug = $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.OwnerUsername; v != nil {
+		argPosition++
+		clause := "owner_id = (select id from users where username = $%d)"
+		where, args = append(where, fmt.Sprintf(clause, argPosition)), append(args, *v)
+	}
+
+	query := "SELECT * from series" + formatWhereClause(where) + " ORDER BY created_at DESC " + formatLimitOffset(filter.Limit, filter.Offset)
+
+	series := make([]*conduit.Series, 0)
+	if err := findMany(ctx, tx, &series, query, args...); err != nil {
+		return series, err
+	}
+
+	for _, s := range series {
+		owner, err := findUserByID(ctx, tx, s.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find series owner: %w", err)
+		}
+		s.Owner = owner
+
+		entries, err := findSeriesEntries(ctx, tx, s)
+		if err != nil {
+			return nil, err
+		}
+		s.Articles = entries
+	}
+
+	return series, nil
+}
+
+func findSeriesEntries(ctx context.Context, tx *sqlx.Tx, series *conduit.Series) ([]*conduit.SeriesEntry, error) {
+	query := `
+	SELECT sa.article_id, sa.position, a.slug, a.title, a.status
+	FROM series_articles sa JOIN articles a ON a.id = sa.article_id
+	WHERE sa.series_id = $1
+	ORDER BY sa.position ASC
+	`
+
+	entries := make([]*conduit.SeriesEntry, 0)
+	if err := findMany(ctx, tx, &entries, query, series.ID); err != nil {
+		return entries, err
+	}
+
+	return entries, nil
+}
+
+// findArticleSeries returns the series block of an article, or nil when the
+// article is not part of a series. Only published articles are counted and
+// linked, so drafts in a series stay hidden.
+func findArticleSeries(ctx context.Context, tx *sqlx.Tx, article *conduit.Article) (*conduit.ArticleSeries, error) {
+	query := `
+	SELECT s.id, s.slug, s.title FROM series s
+	JOIN series_articles sa ON sa.series_id = s.id
+	WHERE sa.article_id = $1
+	`
+
+	series := make([]*conduit.Series, 0)
+	if err := findMany(ctx, tx, &series, query, article.ID); err != nil {
+		return nil, err
+	} else if len(series) == 0 {
+		return nil, nil
+	}
+
+	entries, err := findSeriesEntries(ctx, tx, series[0])
+	if err != nil {
+		return nil, err
+	}
+
+	current := series[0]
+	current.Articles = entries
+	visible := current.PublishedArticles()
+
+	block := &conduit.ArticleSeries{Slug: current.Slug, Title: current.Title}
+
+	for i, e := range visible {
+		if e.ArticleID != article.ID {
+			continue
+		}
+
+		block.Position = i + 1
+		if i > 0 {
+			block.Previous = visible[i-1]
+		}
+		if i+1 < len(visible) {
+			block.Next = visible[i+1]
+		}
+	}
+
+	block.Total = len(visible)
+
+	return block, nil
+}
+
+func uniqueSeriesSlug(ctx context.Context, tx *sqlx.Tx, title string, seriesID uint) (string, error) {
+	base := slug.Make(title)
+	if base == "" {
+		base = "series"
+	}
+
+	query := "SELECT slug FROM series WHERE (slug = $1 OR slug LIKE $2) AND id <> $3"
+
+	taken := make([]string, 0)
+	if err := tx.SelectContext(ctx, &taken, query, base, escapeLike(base)+"-%", seriesID); err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, s := range taken {
+		used[s] = true
+	}
+
+	candidate := base
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	return candidate, nil
+}
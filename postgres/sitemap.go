@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+const sitemapEntries = `
+SELECT 'article' AS kind, slug AS key, updated_at, 0 AS ord, id FROM articles WHERE status = 'published'
+UNION ALL
+SELECT 'profile' AS kind, username AS key, updated_at, 1 AS ord, id FROM users
+`
+
+var _ conduit.SitemapService = (*SitemapService)(nil)
+
+type SitemapService struct {
+	db *DB
+}
+
+func NewSitemapService(db *DB) *SitemapService {
+	return &SitemapService{db}
+}
+
+func (ss *SitemapService) SitemapEntryCount(ctx context.Context) (int, error) {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM ("+sitemapEntries+") e"); err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
+
+func (ss *SitemapService) SitemapEntries(ctx context.Context, limit, offset int) ([]*conduit.SitemapEntry, error) {
+	tx, err := ss.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := "SELECT kind, key, updated_at FROM (" + sitemapEntries + ") e ORDER BY ord ASC, id ASC " + formatLimitOffset(limit, offset)
+
+	entries := make([]*conduit.SitemapEntry, 0)
+	if err := findMany(ctx, tx, &entries, query); err != nil {
+		return nil, err
+	}
+
+	return entries, tx.Commit()
+}
@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
@@ -47,3 +49,452 @@ func findTagByName(ctx context.Context, tx *sqlx.Tx, name string) (*conduit.Tag,
 
 	return ts[0], nil
 }
+
+// similarityThreshold is the pg_trgm similarity from which two tag names are
+// considered near duplicates.
+const similarityThreshold = 0.4
+
+// setSimilarityThreshold makes the % operator, which unlike similarity() can
+// use the trigram index on tag names, match from similarityThreshold for the
+// rest of the transaction.
+func setSimilarityThreshold(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL pg_trgm.similarity_threshold = %v", similarityThreshold))
+	return err
+}
+
+var _ conduit.TagService = (*TagService)(nil)
+
+type TagService struct {
+	db *DB
+}
+
+func NewTagService(db *DB) *TagService {
+	return &TagService{db}
+}
+
+func (ts *TagService) SuggestTags(ctx context.Context, prefix string, limit int) ([]*conduit.Tag, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	tags, err := suggestTags(ctx, tx, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, tx.Commit()
+}
+
+func (ts *TagService) SimilarTags(ctx context.Context, name string) ([]*conduit.Tag, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	tags, err := findSimilarTags(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, tx.Commit()
+}
+
+func suggestTags(ctx context.Context, tx *sqlx.Tx, prefix string, limit int) ([]*conduit.Tag, error) {
+	if err := setSimilarityThreshold(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, name FROM tags
+	WHERE lower(name::text) LIKE lower($1) || '%' OR lower(name::text) % lower($2)
+	ORDER BY lower(name::text) LIKE lower($1) || '%' DESC, similarity(lower(name::text), lower($2)) DESC, name ASC
+	` + formatLimitOffset(limit, 0)
+
+	tags := make([]*conduit.Tag, 0)
+	if err := findMany(ctx, tx, &tags, query, escapeLike(prefix), prefix); err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+func findSimilarTags(ctx context.Context, tx *sqlx.Tx, name string) ([]*conduit.Tag, error) {
+	if err := setSimilarityThreshold(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	query := `
+	SELECT id, name FROM tags
+	WHERE NOT EXISTS (SELECT 1 FROM tags WHERE name = $1)
+		AND lower(name::text) % lower($1)
+	ORDER BY similarity(lower(name::text), lower($1)) DESC, name ASC
+	`
+
+	tags := make([]*conduit.Tag, 0)
+	if err := findMany(ctx, tx, &tags, query, name); err != nil {
+		return tags, err
+	}
+
+	return tags, nil
+}
+
+func (ts *TagService) RenameTag(ctx context.Context, moderator *conduit.User, name, newName string) (*conduit.Tag, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	tag, err := renameTag(ctx, tx, moderator, name, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, tx.Commit()
+}
+
+func (ts *TagService) MergeTags(ctx context.Context, moderator *conduit.User, from, into string) (*conduit.Tag, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	tag, err := mergeTags(ctx, tx, moderator, from, into)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, tx.Commit()
+}
+
+func (ts *TagService) AddTagAlias(ctx context.Context, moderator *conduit.User, name, alias string) error {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := addTagAlias(ctx, tx, moderator, name, alias); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts *TagService) RemoveTagAlias(ctx context.Context, moderator *conduit.User, alias string) error {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := removeTagAlias(ctx, tx, moderator, alias); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts *TagService) DeleteTag(ctx context.Context, moderator *conduit.User, name string) error {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := deleteTag(ctx, tx, moderator, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts *TagService) DeleteUnusedTags(ctx context.Context, moderator *conduit.User) (int64, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	n, err := deleteUnusedTags(ctx, tx, moderator)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+func (ts *TagService) TagAuditLog(ctx context.Context, filter conduit.TagAuditFilter) ([]*conduit.TagAuditEntry, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	entries, err := findTagAuditEntries(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, tx.Commit()
+}
+
+func (ts *TagService) FollowTag(ctx context.Context, user *conduit.User, name string) (*conduit.Tag, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	tag, err := resolveTagByName(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO tag_followings (tag_id, follower_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	if _, err := tx.ExecContext(ctx, query, tag.ID, user.ID); err != nil {
+		return nil, err
+	}
+
+	return tag, tx.Commit()
+}
+
+func (ts *TagService) UnfollowTag(ctx context.Context, user *conduit.User, name string) (*conduit.Tag, error) {
+	tx, err := ts.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	tag, err := resolveTagByName(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "DELETE FROM tag_followings WHERE tag_id = $1 AND follower_id = $2"
+	if _, err := tx.ExecContext(ctx, query, tag.ID, user.ID); err != nil {
+		return nil, err
+	}
+
+	return tag, tx.Commit()
+}
+
+// resolveTagByName finds a tag by its name or, failing that, by one of its aliases.
+func resolveTagByName(ctx context.Context, tx *sqlx.Tx, name string) (*conduit.Tag, error) {
+	tag, err := findTagByName(ctx, tx, name)
+	if err == nil || !errors.Is(err, conduit.ErrNotFound) {
+		return tag, err
+	}
+
+	query := "SELECT * FROM tags WHERE id = (SELECT tag_id FROM tag_aliases WHERE alias = $1)"
+	tags := make([]*conduit.Tag, 0)
+	if err := findMany(ctx, tx, &tags, query, name); err != nil {
+		return nil, err
+	} else if len(tags) == 0 {
+		return nil, conduit.ErrNotFound
+	}
+
+	return tags[0], nil
+}
+
+// ensureTagNameFree fails with ErrDuplicateTag when name is already used by a
+// tag or an alias, other than by the tag with id except.
+func ensureTagNameFree(ctx context.Context, tx *sqlx.Tx, name string, except uint) error {
+	tag, err := resolveTagByName(ctx, tx, name)
+	switch {
+	case err == nil && tag.ID == except:
+		return nil
+	case err == nil:
+		return conduit.ErrDuplicateTag
+	case errors.Is(err, conduit.ErrNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+func renameTag(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User, name, newName string) (*conduit.Tag, error) {
+	tag, err := findTagByName(ctx, tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// names compare case-insensitively, so a tag may be renamed to another
+	// case of its name or to one of its own aliases
+	if err := ensureTagNameFree(ctx, tx, newName, tag.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tags SET name = $1 WHERE id = $2", newName, tag.ID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tag_aliases WHERE tag_id = $1 AND alias = $2", tag.ID, newName); err != nil {
+		return nil, err
+	}
+
+	oldName := tag.Name
+	tag.Name = newName
+
+	return tag, recordTagAction(ctx, tx, moderator, conduit.TagActionRename, oldName, newName)
+}
+
+func mergeTags(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User, from, into string) (*conduit.Tag, error) {
+	source, err := findTagByName(ctx, tx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := findTagByName(ctx, tx, into)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.ID == target.ID {
+		return nil, conduit.ErrDuplicateTag
+	}
+
+	queries := []string{
+		`INSERT INTO article_tags (article_id, tag_id)
+		SELECT article_id, $2 FROM article_tags WHERE tag_id = $1
+		ON CONFLICT DO NOTHING`,
+		`INSERT INTO tag_followings (tag_id, follower_id, followed_on)
+		SELECT $2, follower_id, followed_on FROM tag_followings WHERE tag_id = $1
+		ON CONFLICT DO NOTHING`,
+		"UPDATE tag_aliases SET tag_id = $2 WHERE tag_id = $1",
+		"DELETE FROM tags WHERE id = $1",
+	}
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, source.ID, target.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)", source.Name, target.ID); err != nil {
+		return nil, err
+	}
+
+	return target, recordTagAction(ctx, tx, moderator, conduit.TagActionMerge, source.Name, target.Name)
+}
+
+func addTagAlias(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User, name, alias string) error {
+	tag, err := findTagByName(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureTagNameFree(ctx, tx, alias, 0); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO tag_aliases (alias, tag_id) VALUES ($1, $2)", alias, tag.ID); err != nil {
+		return err
+	}
+
+	return recordTagAction(ctx, tx, moderator, conduit.TagActionAddAlias, tag.Name, alias)
+}
+
+func removeTagAlias(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User, alias string) error {
+	query := `
+	DELETE FROM tag_aliases a USING tags t
+	WHERE a.tag_id = t.id AND a.alias = $1
+	RETURNING t.name`
+
+	var name string
+	if err := tx.QueryRowxContext(ctx, query, alias).Scan(&name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return conduit.ErrNotFound
+		}
+		return err
+	}
+
+	return recordTagAction(ctx, tx, moderator, conduit.TagActionRemoveAlias, name, alias)
+}
+
+func deleteTag(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User, name string) error {
+	tag, err := findTagByName(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+
+	var inUse bool
+	if err := tx.GetContext(ctx, &inUse, "SELECT EXISTS (SELECT 1 FROM article_tags WHERE tag_id = $1)", tag.ID); err != nil {
+		return err
+	}
+
+	if inUse {
+		return conduit.ErrTagInUse
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = $1", tag.ID); err != nil {
+		return err
+	}
+
+	return recordTagAction(ctx, tx, moderator, conduit.TagActionDelete, tag.Name, "")
+}
+
+func deleteUnusedTags(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User) (int64, error) {
+	query := `
+	DELETE FROM tags WHERE NOT EXISTS (
+		SELECT 1 FROM article_tags WHERE tag_id = tags.id
+	) RETURNING name`
+
+	names := make([]string, 0)
+	if err := tx.SelectContext(ctx, &names, query); err != nil {
+		return 0, err
+	}
+
+	for _, name := range names {
+		if err := recordTagAction(ctx, tx, moderator, conduit.TagActionDelete, name, ""); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(names)), nil
+}
+
+func recordTagAction(ctx context.Context, tx *sqlx.Tx, moderator *conduit.User, action, tagName, targetName string) error {
+	query := `
+	INSERT INTO tag_audit_log (action, tag_name, target_name, moderator_id)
+	VALUES ($1, $2, NULLIF($3, ''), $4)`
+
+	_, err := tx.ExecContext(ctx, query, action, tagName, targetName, moderator.ID)
+	return err
+}
+
+func findTagAuditEntries(ctx context.Context, tx *sqlx.Tx, filter conduit.TagAuditFilter) ([]*conduit.TagAuditEntry, error) {
+	where, args := []string{}, []interface{}{}
+	argPosition := 0
+
+	if v := filter.TagName; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("(tag_name = $%[1]d OR target_name = $%[1]d)", argPosition)), append(args, *v)
+	}
+
+	query := `
+	SELECT id, action, tag_name, COALESCE(target_name, '') AS target_name, moderator_id, created_at
+	FROM tag_audit_log` + formatWhereClause(where) + " ORDER BY id DESC " + formatLimitOffset(filter.Limit, filter.Offset)
+
+	entries := make([]*conduit.TagAuditEntry, 0)
+	if err := findMany(ctx, tx, &entries, query, args...); err != nil {
+		return entries, err
+	}
+
+	return entries, nil
+}
@@ -52,6 +52,26 @@ func (us *UserService) UserByEmail(ctx context.Context, email string) (*conduit.
 	return user, nil
 }
 
+func (us *UserService) UserByUsername(ctx context.Context, username string) (*conduit.User, error) {
+	tx, err := us.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	user, err := findOneUser(ctx, tx, conduit.UserFilter{Username: &username})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (us *UserService) Authenticate(ctx context.Context, email, password string) (*conduit.User, error) {
 	user, err := us.UserByEmail(ctx, email)
 	if err != nil {
@@ -87,6 +107,110 @@ func (us *UserService) UpdateUser(ctx context.Context, user *conduit.User, patch
 	return nil
 }
 
+func (us *UserService) FollowUser(ctx context.Context, follower, user *conduit.User) error {
+	tx, err := us.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "INSERT INTO followings (following_id, follower_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+
+	res, err := tx.ExecContext(ctx, query, user.ID, follower.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		n := conduit.Notification{Kind: conduit.NotificationFollow, UserID: user.ID, ActorID: follower.ID}
+		if err := notify(ctx, tx, &n); err != nil {
+			return err
+		}
+
+		data := followEvent{Follower: follower.Username, User: user.Username}
+		if err := enqueueWebhookDeliveries(ctx, tx, conduit.WebhookUserFollowed, user.ID, data); err != nil {
+			return err
+		}
+
+		change := followChange{FollowerID: follower.ID, UserID: user.ID}
+		if err := recordDomainEvent(ctx, tx, conduit.AggregateUser, user.ID, conduit.UserFollowed, change); err != nil {
+			return err
+		}
+	}
+
+	if user.Followers, err = getFollowers(ctx, tx, user); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (us *UserService) UnfollowUser(ctx context.Context, follower, user *conduit.User) error {
+	tx, err := us.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "DELETE FROM followings WHERE following_id = $1 AND follower_id = $2"
+
+	res, err := tx.ExecContext(ctx, query, user.ID, follower.ID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		change := followChange{FollowerID: follower.ID, UserID: user.ID}
+		if err := recordDomainEvent(ctx, tx, conduit.AggregateUser, user.ID, conduit.UserUnfollowed, change); err != nil {
+			return err
+		}
+	}
+
+	if user.Followers, err = getFollowers(ctx, tx, user); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (us *UserService) BlockUser(ctx context.Context, blocker, user *conduit.User) error {
+	tx, err := us.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	if _, err := tx.ExecContext(ctx, query, blocker.ID, user.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (us *UserService) UnblockUser(ctx context.Context, blocker, user *conduit.User) error {
+	tx, err := us.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := "DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2"
+	if _, err := tx.ExecContext(ctx, query, blocker.ID, user.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func createUser(ctx context.Context, tx *sqlx.Tx, user *conduit.User) error {
 	query := `
 	INSERT INTO users (email, username, bio, image, password_hash)
@@ -105,7 +229,7 @@ func createUser(ctx context.Context, tx *sqlx.Tx, user *conduit.User) error {
 		}
 	}
 
-	return nil
+	return recordUserChange(ctx, tx, conduit.UserCreated, user)
 }
 
 func findUserByID(ctx context.Context, tx *sqlx.Tx, id uint) (*conduit.User, error) {
@@ -199,7 +323,7 @@ func updateUser(ctx context.Context, tx *sqlx.Tx, user *conduit.User, patch cond
 		return conduit.ErrInternal
 	}
 
-	return nil
+	return recordUserChange(ctx, tx, conduit.UserUpdated, user)
 }
 
 func getFollowers(ctx context.Context, tx *sqlx.Tx, user *conduit.User) ([]*conduit.User, error) {
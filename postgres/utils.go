@@ -87,3 +87,8 @@ func asSlicePtrValue(v interface{}) (reflect.Value, error) {
 	}
 	return reflect.ValueOf(v), nil
 }
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,319 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+var _ conduit.WebhookService = (*WebhookService)(nil)
+
+type WebhookService struct {
+	db *DB
+}
+
+func NewWebhookService(db *DB) *WebhookService {
+	return &WebhookService{db}
+}
+
+// webhookRow scans a webhooks row, whose events are a text array.
+type webhookRow struct {
+	conduit.Webhook
+	Events pq.StringArray
+}
+
+func (ws *WebhookService) CreateWebhook(ctx context.Context, webhook *conduit.Webhook) error {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO webhooks (owner_id, scope, url, secret, events, active)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at
+	`
+
+	args := []interface{}{webhook.OwnerID, webhook.Scope, webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.Active}
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ws *WebhookService) Webhooks(ctx context.Context, filter conduit.WebhookFilter) ([]*conduit.Webhook, error) {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	webhooks, err := findWebhooks(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, tx.Commit()
+}
+
+func (ws *WebhookService) UpdateWebhook(ctx context.Context, webhook *conduit.Webhook, patch conduit.WebhookPatch) error {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if v := patch.URL; v != nil {
+		webhook.URL = *v
+	}
+
+	if v := patch.Events; v != nil {
+		webhook.Events = v
+	}
+
+	if v := patch.Active; v != nil {
+		webhook.Active = *v
+	}
+
+	query := `
+	UPDATE webhooks SET url = $1, events = $2, active = $3, updated_at = NOW()
+	WHERE id = $4 RETURNING updated_at
+	`
+
+	args := []interface{}{webhook.URL, pq.Array(webhook.Events), webhook.Active, webhook.ID}
+	if err := tx.QueryRowxContext(ctx, query, args...).Scan(&webhook.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, webhook *conduit.Webhook) error {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1", webhook.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ws *WebhookService) WebhookDeliveries(ctx context.Context, webhook *conduit.Webhook, filter conduit.WebhookDeliveryFilter) ([]*conduit.WebhookDelivery, error) {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	where, args := []string{"webhook_id = $1"}, []interface{}{webhook.ID}
+	argPosition := 1
+
+	if v := filter.ID; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("id = $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.Status; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("status = $%d", argPosition)), append(args, *v)
+	}
+
+	query := "SELECT * FROM webhook_deliveries" + formatWhereClause(where) + " ORDER BY id DESC " + formatLimitOffset(filter.Limit, filter.Offset)
+
+	deliveries := make([]*conduit.WebhookDelivery, 0)
+	if err := findMany(ctx, tx, &deliveries, query, args...); err != nil {
+		return nil, err
+	}
+
+	return deliveries, tx.Commit()
+}
+
+func (ws *WebhookService) Redeliver(ctx context.Context, delivery *conduit.WebhookDelivery) (*conduit.WebhookDelivery, error) {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO webhook_deliveries (webhook_id, event, payload)
+	SELECT webhook_id, event, payload FROM webhook_deliveries WHERE id = $1
+	RETURNING *
+	`
+
+	redelivery := conduit.WebhookDelivery{}
+	if err := tx.QueryRowxContext(ctx, query, delivery.ID).StructScan(&redelivery); err != nil {
+		return nil, err
+	}
+
+	return &redelivery, tx.Commit()
+}
+
+func (ws *WebhookService) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*conduit.WebhookDelivery, error) {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// pushing next_attempt_at past the lease hides the deliveries from
+	// other workers; a worker that dies mid-delivery is retried after it
+	query := `
+	UPDATE webhook_deliveries SET next_attempt_at = $2
+	WHERE id IN (
+		SELECT id FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY next_attempt_at ASC
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING *
+	`
+
+	deliveries := make([]*conduit.WebhookDelivery, 0)
+	if err := findMany(ctx, tx, &deliveries, query, now, now.Add(lease), limit); err != nil {
+		return nil, err
+	}
+
+	webhooks := make(map[uint]*conduit.Webhook)
+	for _, d := range deliveries {
+		webhook, ok := webhooks[d.WebhookID]
+		if !ok {
+			found, err := findWebhooks(ctx, tx, conduit.WebhookFilter{ID: &d.WebhookID})
+			if err != nil {
+				return nil, err
+			} else if len(found) == 0 {
+				return nil, conduit.ErrNotFound
+			}
+			webhook = found[0]
+			webhooks[d.WebhookID] = webhook
+		}
+
+		d.Webhook = webhook
+	}
+
+	return deliveries, tx.Commit()
+}
+
+func (ws *WebhookService) RecordDeliveryAttempt(ctx context.Context, delivery *conduit.WebhookDelivery, attempt conduit.DeliveryAttempt, retryAt *time.Time) error {
+	tx, err := ws.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var statusCode *int
+	if attempt.StatusCode != 0 {
+		statusCode = &attempt.StatusCode
+	}
+
+	var lastError *string
+	if attempt.Err != nil {
+		msg := attempt.Err.Error()
+		lastError = &msg
+	}
+
+	status, deliveredAt := conduit.DeliveryPending, (*time.Time)(nil)
+	switch {
+	case attempt.Succeeded():
+		now := time.Now()
+		status, deliveredAt, retryAt = conduit.DeliveryDelivered, &now, nil
+	case retryAt == nil:
+		status = conduit.DeliveryDead
+	}
+
+	query := `
+	UPDATE webhook_deliveries
+	SET status = $1, attempts = attempts + 1, next_attempt_at = $2, last_status_code = $3, last_error = $4, delivered_at = $5
+	WHERE id = $6
+	RETURNING *
+	`
+
+	args := []interface{}{status, retryAt, statusCode, lastError, deliveredAt, delivery.ID}
+	if err := tx.QueryRowxContext(ctx, query, args...).StructScan(delivery); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findWebhooks(ctx context.Context, tx *sqlx.Tx, filter conduit.WebhookFilter) ([]*conduit.Webhook, error) {
+	where, args := []string{}, []interface{}{}
+	argPosition := 0
+
+	if v := filter.ID; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("id = $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.OwnerID; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("owner_id = $%d", argPosition)), append(args, *v)
+	}
+
+	if v := filter.Scope; v != nil {
+		argPosition++
+		where, args = append(where, fmt.Sprintf("scope = $%d", argPosition)), append(args, *v)
+	}
+
+	query := "SELECT * FROM webhooks" + formatWhereClause(where) + " ORDER BY id ASC " + formatLimitOffset(filter.Limit, filter.Offset)
+
+	rows := make([]*webhookRow, 0)
+	if err := findMany(ctx, tx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]*conduit.Webhook, len(rows))
+	for i, row := range rows {
+		row.Webhook.Events = row.Events
+		webhooks[i] = &row.Webhook
+	}
+
+	return webhooks, nil
+}
+
+type webhookPayload struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// enqueueWebhookDeliveries queues the event for the site webhooks and the
+// user webhooks of ownerID that subscribe to it. They are sent once the
+// transaction commits and the dispatcher picks them up.
+func enqueueWebhookDeliveries(ctx context.Context, tx *sqlx.Tx, event string, ownerID uint, data interface{}) error {
+	payload, err := json.Marshal(webhookPayload{event, time.Now().UTC(), data})
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO webhook_deliveries (webhook_id, event, payload)
+	SELECT id, $1::varchar, $3::jsonb FROM webhooks
+	WHERE active AND $1::varchar = ANY(events) AND (scope = 'site' OR owner_id = $2)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, event, ownerID, string(payload)); err != nil {
+		return fmt.Errorf("cannot queue %s webhooks: %w", event, err)
+	}
+
+	return nil
+}
@@ -1,20 +1,29 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/gosimple/slug"
+	"github.com/gorilla/mux"
 	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
 )
 
 func (s *Server) createArticle() http.HandlerFunc {
 	type Input struct {
 		Article struct {
-			Title       string   `json:"title" validate:"required"`
-			Description string   `json:"description"`
-			Body        string   `json:"body" validate:"required"`
-			Tags        []string `json:"tagList"`
+			Title       string     `json:"title" validate:"required"`
+			Description string     `json:"description"`
+			Body        string     `json:"body" validate:"required"`
+			Tags        []string   `json:"tagList"`
+			Status      string     `json:"status" validate:"omitempty,oneof=draft published scheduled"`
+			PublishAt   *time.Time `json:"publishAt"`
 		} `json:"article"`
 	}
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -33,10 +42,18 @@ func (s *Server) createArticle() http.HandlerFunc {
 		article := conduit.Article{
 			Title:       input.Article.Title,
 			Body:        input.Article.Body,
-			Slug:        slug.Make(input.Article.Title),
 			Description: input.Article.Description,
 		}
 
+		if input.Article.Status == "" {
+			input.Article.Status = conduit.ArticleStatusPublished
+		}
+
+		if err := article.SetStatus(input.Article.Status, input.Article.PublishAt, time.Now()); err != nil {
+			articleWriteError(w, err)
+			return
+		}
+
 		article.AddTags(input.Article.Tags...)
 		user := userFromContext(r.Context())
 		article.Author = user
@@ -46,30 +63,43 @@ func (s *Server) createArticle() http.HandlerFunc {
 			return
 		}
 
-		if err := s.articleService.CreateArticle(r.Context(), &article); err != nil {
+		warnings, err := s.similarTagWarnings(r.Context(), article.Tags)
+		if err != nil {
 			serverError(w, err)
 			return
 		}
 
-		writeJSON(w, http.StatusOK, M{"article": article})
-	}
-}
+		bodyHTML, err := s.renderer.Render(article.Body)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		article.BodyHTML = bodyHTML
 
-func (s *Server) listArticles() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		query := r.URL.Query()
-		filter := conduit.ArticleFilter{}
+		plainBody := s.renderer.PlainText(article.Body)
+		article.SetBodyStats(plainBody)
 
-		if v := query.Get("author"); v != "" {
-			filter.AuthorUsername = &v
+		if article.Description == "" {
+			article.Description = conduit.Summarize(plainBody)
 		}
 
-		if v := query.Get("tag"); v != "" {
-			filter.Tag = &v
+		if err := s.articleService.CreateArticle(r.Context(), &article); err != nil {
+			articleWriteError(w, err)
+			return
 		}
 
-		if v := query.Get("favorited"); v != "" {
-			filter.FavoritedBy = &v
+		s.indexArticle(r.Context(), &article)
+		s.presentArticles(r, &article)
+
+		writeJSON(w, http.StatusOK, withWarnings(M{"article": article}, warnings))
+	}
+}
+
+func (s *Server) listArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := articleFilterFromQuery(w, r)
+		if !ok {
+			return
 		}
 
 		articles, err := s.articleService.Articles(r.Context(), filter)
@@ -77,22 +107,78 @@ func (s *Server) listArticles() http.HandlerFunc {
 			serverError(w, err)
 			return
 		}
-		user := userFromContext(r.Context())
-		for _, a := range articles {
-			a.SetAuthorProfile(user)
-			a.Favorited = a.UserHasFavorite(user)
-		}
+		s.presentArticles(r, articles...)
 
 		writeJSON(w, http.StatusOK, M{"articles": articles})
 	}
 }
 
+// articleFilterFromQuery reads the filter of an article listing from the
+// query string, shared by the JSON listing and the feeds.
+func articleFilterFromQuery(w http.ResponseWriter, r *http.Request) (conduit.ArticleFilter, bool) {
+	query := r.URL.Query()
+	filter := conduit.ArticleFilter{}
+
+	if v := query.Get("author"); v != "" {
+		filter.AuthorUsername = &v
+	}
+
+	if v := query.Get("tag"); v != "" {
+		filter.Tag = &v
+	}
+
+	if v := query.Get("favorited"); v != "" {
+		filter.FavoritedBy = &v
+	}
+
+	if v := query.Get("series"); v != "" {
+		filter.SeriesSlug = &v
+	}
+
+	if v := query.Get("q"); v != "" {
+		filter.Query = &v
+	}
+
+	switch v := query.Get("sort"); v {
+	case "", conduit.SortReadingTime, conduit.SortReadingTimeDesc:
+		filter.Sort = v
+	default:
+		errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"sort": []string{`must be "readingTime" or "-readingTime"`}})
+		return filter, false
+	}
+
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	return filter, true
+}
+
+// feedSourceFromQuery reads ?source=, which narrows a user's feed to the
+// followed authors or tags. It is empty for both.
+func feedSourceFromQuery(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch v := r.URL.Query().Get("source"); v {
+	case "", conduit.FeedSourceAuthors, conduit.FeedSourceTags:
+		return v, true
+	default:
+		errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"source": []string{`must be "authors" or "tags"`}})
+		return "", false
+	}
+}
+
 func (s *Server) articleFeed() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
 		filter := conduit.ArticleFilter{}
 		limit, _ := strconv.Atoi(query.Get("limit"))
 		filter.Limit = limit
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		source, ok := feedSourceFromQuery(w, r)
+		if !ok {
+			return
+		}
+		filter.FeedSource = source
+
 		ctx := r.Context()
 		articles, err := s.articleService.ArticleFeed(ctx, userFromContext(ctx), filter)
 		if err != nil {
@@ -100,10 +186,442 @@ func (s *Server) articleFeed() http.HandlerFunc {
 			return
 		}
 
-		for _, a := range articles {
-			a.SetAuthorProfile(userFromContext(ctx))
+		s.presentArticles(r, articles...)
+
+		writeJSON(w, http.StatusOK, M{"articles": articles})
+	}
+}
+
+// getArticle returns a published article, or an unpublished one to those who
+// can edit it. Slugs an article had before its title changed redirect to the
+// current slug.
+func (s *Server) getArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		user := userFromContext(ctx)
+		slug := mux.Vars(r)["slug"]
+
+		article, err := s.articleService.ArticleBySlug(ctx, slug)
+		if err != nil {
+			if !errors.Is(err, conduit.ErrNotFound) {
+				serverError(w, err)
+				return
+			}
+
+			current, err := s.articleService.CurrentSlug(ctx, slug)
+			switch {
+			case err == nil:
+				http.Redirect(w, r, slugRedirectPath(r, current), http.StatusMovedPermanently)
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		if !article.IsPublished() && !article.CanEdit(user) {
+			notFoundError(w)
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) updateArticle() http.HandlerFunc {
+	type Input struct {
+		Article struct {
+			Title       *string    `json:"title,omitempty"`
+			Description *string    `json:"description,omitempty"`
+			Body        *string    `json:"body,omitempty"`
+			Tags        []string   `json:"tagList,omitempty"`
+			Status      *string    `json:"status,omitempty"`
+			PublishAt   *time.Time `json:"publishAt,omitempty"`
+		} `json:"article"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		article, ok := s.editableArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		user := userFromContext(ctx)
+
+		patch := conduit.ArticlePatch{
+			Title:       input.Article.Title,
+			Description: input.Article.Description,
+			Body:        input.Article.Body,
+			Status:      input.Article.Status,
+			PublishAt:   input.Article.PublishAt,
+			Editor:      user,
+		}
+
+		var warnings []M
+
+		if input.Article.Tags != nil {
+			patch.Tags = conduit.NormalizeTagNames(input.Article.Tags)
+
+			tags := make([]*conduit.Tag, len(patch.Tags))
+			for i, name := range patch.Tags {
+				tags[i] = &conduit.Tag{Name: name}
+			}
+
+			var err error
+			warnings, err = s.similarTagWarnings(ctx, tags)
+			if err != nil {
+				serverError(w, err)
+				return
+			}
+		}
+
+		if err := s.renderPatch(article, &patch); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		if err := s.articleService.UpdateArticle(ctx, article, patch); err != nil {
+			articleWriteError(w, err)
+			return
+		}
+
+		s.indexArticle(ctx, article)
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, withWarnings(M{"article": article}, warnings))
+	}
+}
+
+func (s *Server) deleteArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.ownedArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := s.articleService.DeleteArticle(ctx, article); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		if err := s.searchIndex.Delete(ctx, article); err != nil {
+			log.Printf("cannot remove article %d from search index: %v", article.ID, err)
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+func (s *Server) favoriteArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := s.articleService.FavoriteArticle(ctx, userFromContext(ctx), article); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) unfavoriteArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := s.articleService.UnfavoriteArticle(ctx, userFromContext(ctx), article); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) searchArticles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		q := conduit.SearchQuery{Text: query.Get("q")}
+
+		if q.Text == "" {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"q": []string{"this field is required"}})
+			return
+		}
+
+		if v := query.Get("tag"); v != "" {
+			q.Tag = &v
+		}
+
+		if v := query.Get("author"); v != "" {
+			q.AuthorUsername = &v
+		}
+
+		q.Limit, _ = strconv.Atoi(query.Get("limit"))
+		q.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		if q.Limit < 0 {
+			q.Limit = 0
+		}
+
+		if q.Offset < 0 {
+			q.Offset = 0
+		}
+
+		ctx := r.Context()
+		result, err := s.searchIndex.Query(ctx, q)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		articles := make([]*conduit.Article, 0, len(result.Hits))
+
+		if len(result.Hits) > 0 {
+			ids := make([]uint, len(result.Hits))
+			for i, hit := range result.Hits {
+				ids[i] = hit.ArticleID
+			}
+
+			found, err := s.articleService.Articles(ctx, conduit.ArticleFilter{IDs: ids})
+			if err != nil {
+				serverError(w, err)
+				return
+			}
+
+			byID := make(map[uint]*conduit.Article, len(found))
+			for _, a := range found {
+				byID[a.ID] = a
+			}
+
+			// keep the ranking order of the search backend
+			for _, hit := range result.Hits {
+				a, ok := byID[hit.ArticleID]
+				if !ok {
+					continue
+				}
+				a.Highlight = hit.Highlight
+				a.Rank = hit.Score
+				articles = append(articles, a)
+			}
+
+			s.presentArticles(r, articles...)
+		}
+
+		writeJSON(w, http.StatusOK, M{
+			"articles":      articles,
+			"articlesCount": result.Total,
+			"facets":        result.Facets,
+		})
+	}
+}
+
+func (s *Server) listDrafts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		user := userFromContext(r.Context())
+		filter := conduit.ArticleFilter{AuthorID: &user.ID}
+
+		switch v := query.Get("status"); v {
+		case "":
+			status := conduit.ArticleStatusDraft
+			filter.Status = &status
+		case conduit.ArticleStatusDraft, conduit.ArticleStatusScheduled, conduit.ArticleStatusArchived:
+			filter.Status = &v
+		default:
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"status": []string{`must be "draft", "scheduled" or "archived"`}})
+			return
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		articles, err := s.articleService.Articles(r.Context(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
 		}
 
+		s.presentArticles(r, articles...)
+
 		writeJSON(w, http.StatusOK, M{"articles": articles})
 	}
 }
+
+// renderPatch fills in the fields derived from the body: the cached HTML, the
+// reading stats and, when the article is left without one, the description.
+func (s *Server) renderPatch(article *conduit.Article, patch *conduit.ArticlePatch) error {
+	body, description := article.Body, article.Description
+	if v := patch.Body; v != nil {
+		body = *v
+	}
+	if v := patch.Description; v != nil {
+		description = *v
+	}
+
+	if patch.Body != nil {
+		bodyHTML, err := s.renderer.Render(body)
+		if err != nil {
+			return err
+		}
+		patch.BodyHTML = &bodyHTML
+
+		wordCount := len(strings.Fields(s.renderer.PlainText(body)))
+		patch.WordCount = &wordCount
+	}
+
+	if description == "" && (patch.Body != nil || patch.Description != nil) {
+		generated := conduit.Summarize(s.renderer.PlainText(body))
+		patch.Description = &generated
+	}
+
+	return nil
+}
+
+// presentArticles fills in the fields that depend on the viewer before the
+// articles are written out. Rendered HTML is opt-in, so it is only kept for
+// clients asking for it with ?render=html or an Accept profile of "html".
+func (s *Server) presentArticles(r *http.Request, articles ...*conduit.Article) {
+	user := userFromContext(r.Context())
+	withHTML := wantsBodyHTML(r)
+
+	for _, a := range articles {
+		a.SetAuthorProfile(user)
+		a.Favorited = a.UserHasFavorite(user)
+
+		if a.Reactions == nil {
+			a.Reactions = conduit.NewReactions()
+		}
+
+		if !withHTML {
+			a.BodyHTML = ""
+		} else if a.BodyHTML == "" && a.Body != "" {
+			// rows written before bodies were rendered
+			bodyHTML, err := s.renderer.Render(a.Body)
+			if err != nil {
+				log.Printf("cannot render article %d: %v", a.ID, err)
+			}
+			a.BodyHTML = bodyHTML
+		}
+	}
+
+	if user.IsAnonymous() {
+		return
+	}
+
+	if err := s.bookmarkService.SetBookmarked(r.Context(), user, articles...); err != nil {
+		log.Printf("cannot load bookmarks of user %d: %v", user.ID, err)
+	}
+
+	targets := make([]conduit.ReactionTarget, len(articles))
+	for i, a := range articles {
+		targets[i] = a.ReactionTarget()
+	}
+
+	mine, err := s.reactionService.ViewerReactions(r.Context(), user, targets...)
+	if err != nil {
+		log.Printf("cannot load reactions of user %d: %v", user.ID, err)
+		return
+	}
+
+	for _, a := range articles {
+		a.Reactions.Mine = mine[a.ReactionTarget()]
+	}
+}
+
+func wantsBodyHTML(r *http.Request) bool {
+	if r.URL.Query().Get("render") == "html" {
+		return true
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err == nil && mediaType == "application/json" && params["profile"] == "html" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// indexArticle keeps the search backend in sync after a write. Only published
+// articles are searchable. A failure only leaves search results stale, so it
+// is logged rather than failing the request.
+func (s *Server) indexArticle(ctx context.Context, article *conduit.Article) {
+	var err error
+	if article.IsPublished() {
+		err = s.searchIndex.Index(ctx, article)
+	} else {
+		err = s.searchIndex.Delete(ctx, article)
+	}
+
+	if err != nil {
+		log.Printf("cannot index article %d: %v", article.ID, err)
+	}
+}
+
+// slugRedirectPath is the path of the route r matched with slug, escaped, in
+// place of the old one, so redirects keep the prefix the route is served at.
+func slugRedirectPath(r *http.Request, slug string) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil && strings.Contains(tpl, "{slug}") {
+			return strings.Replace(tpl, "{slug}", url.PathEscape(slug), 1)
+		}
+	}
+
+	return "/articles/" + url.PathEscape(slug)
+}
+
+// reindexArticles refreshes the search documents of the articles matching
+// filter after a change made outside of them, such as a renamed tag or
+// author.
+func (s *Server) reindexArticles(ctx context.Context, filter conduit.ArticleFilter) {
+	articles, err := s.articleService.Articles(ctx, filter)
+	if err != nil {
+		log.Printf("cannot reindex articles: %v", err)
+		return
+	}
+
+	for _, a := range articles {
+		s.indexArticle(ctx, a)
+	}
+}
+
+func articleWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, conduit.ErrInvalidPublishAt):
+		errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"publishAt": []string{err.Error()}})
+	case errors.Is(err, conduit.ErrInvalidArticleStatus):
+		errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"status": []string{`must be "draft", "published", "scheduled" or "archived"`}})
+	case errors.Is(err, conduit.ErrDuplicateSlug):
+		errorResponse(w, http.StatusConflict, ErrorM{"slug": []string{"an article with this slug already exists, please retry"}})
+	default:
+		serverError(w, err)
+	}
+}
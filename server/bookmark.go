@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// bookmarkArticle saves the article to the user's read-later list. The body
+// is optional and only names the folder to file the bookmark under.
+func (s *Server) bookmarkArticle() http.HandlerFunc {
+	type Input struct {
+		Folder string `json:"folder" validate:"max=64"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil && !errors.Is(err, io.EOF) {
+			badRequestError(w)
+			return
+		}
+
+		input.Folder = strings.TrimSpace(input.Folder)
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		user := userFromContext(ctx)
+
+		if err := s.bookmarkService.BookmarkArticle(ctx, user, article, input.Folder); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) unbookmarkArticle() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.articleFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := s.bookmarkService.UnbookmarkArticle(ctx, userFromContext(ctx), article); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) listBookmarks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		user := userFromContext(r.Context())
+		filter := conduit.ArticleFilter{BookmarkedBy: &user.ID}
+
+		if _, ok := query["folder"]; ok {
+			folder := strings.TrimSpace(query.Get("folder"))
+			filter.BookmarkFolder = &folder
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		articles, err := s.articleService.Articles(r.Context(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		s.presentArticles(r, articles...)
+
+		writeJSON(w, http.StatusOK, M{"articles": articles})
+	}
+}
+
+func (s *Server) listBookmarkFolders() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		folders, err := s.bookmarkService.BookmarkFolders(r.Context(), userFromContext(r.Context()))
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"folders": folders})
+	}
+}
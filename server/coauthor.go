@@ -0,0 +1,223 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) inviteCoAuthor() http.HandlerFunc {
+	type Input struct {
+		Username string `json:"username" validate:"required"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		article, ok := s.ownedArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		owner := userFromContext(ctx)
+
+		user, err := s.userService.UserByUsername(ctx, input.Username)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"username": []string{"user does not exist"}})
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		if article.IsOwnedBy(user) {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"username": []string{"the owner cannot be a co-author"}})
+			return
+		}
+
+		invitation, err := s.coAuthorService.InviteCoAuthor(ctx, article, user, owner)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrDuplicateCoAuthor):
+				errorResponse(w, http.StatusConflict, ErrorM{"username": []string{"this user is already invited"}})
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, M{"invitation": invitation})
+	}
+}
+
+func (s *Server) removeCoAuthor() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.ownedArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		user, err := s.userService.UserByUsername(ctx, mux.Vars(r)["username"])
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		if err := s.coAuthorService.RemoveCoAuthor(ctx, article, user); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+// reorderCoAuthors sets the order co-authors are listed in. Every accepted
+// co-author must be listed exactly once.
+func (s *Server) reorderCoAuthors() http.HandlerFunc {
+	type Input struct {
+		CoAuthors []string `json:"coAuthors" validate:"required"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		article, ok := s.ownedArticle(w, r)
+		if !ok {
+			return
+		}
+
+		byUsername := make(map[string]uint, len(article.CoAuthors))
+		for _, u := range article.CoAuthors {
+			byUsername[u.Username] = u.ID
+		}
+
+		ids := make([]uint, 0, len(input.CoAuthors))
+		for _, username := range input.CoAuthors {
+			id, ok := byUsername[username]
+			if !ok {
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"coAuthors": []string{username + " is not a co-author or is listed twice"}})
+				return
+			}
+
+			delete(byUsername, username)
+			ids = append(ids, id)
+		}
+
+		if len(byUsername) != 0 {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"coAuthors": []string{"every co-author must be listed"}})
+			return
+		}
+
+		if err := s.coAuthorService.ReorderCoAuthors(r.Context(), article, ids); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) listInvitations() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitations, err := s.coAuthorService.CoAuthorInvitations(r.Context(), userFromContext(r.Context()))
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"invitations": invitations})
+	}
+}
+
+func (s *Server) acceptInvitation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.articleFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := s.coAuthorService.AcceptCoAuthorInvitation(ctx, article, userFromContext(ctx)); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		article, ok = s.articleFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+// declineInvitation drops a pending invitation. A co-author who already
+// accepted uses it to leave the article.
+func (s *Server) declineInvitation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.articleFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		if err := s.coAuthorService.RemoveCoAuthor(ctx, article, userFromContext(ctx)); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
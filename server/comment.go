@@ -0,0 +1,299 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) createComment() http.HandlerFunc {
+	type Input struct {
+		Comment struct {
+			Body     string `json:"body" validate:"required"`
+			ParentID *uint  `json:"parentId,omitempty"`
+		} `json:"comment"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input.Comment); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		comment := conduit.Comment{
+			ArticleID: article.ID,
+			ParentID:  input.Comment.ParentID,
+			Body:      input.Comment.Body,
+			Author:    userFromContext(r.Context()),
+		}
+
+		if err := s.commentService.CreateComment(r.Context(), &comment); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"parentId": []string{"comment does not exist"}})
+			case errors.Is(err, conduit.ErrCommentTooDeep):
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"parentId": []string{"replies cannot nest any deeper"}})
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		s.presentComments(r, &comment)
+
+		writeJSON(w, http.StatusCreated, M{"comment": comment})
+	}
+}
+
+// listComments returns the article's comments oldest first. By default they
+// are a flat list referencing their parents; ?view=tree nests the replies
+// under their parents instead, in which case the whole thread is returned.
+func (s *Server) listComments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		view := query.Get("view")
+
+		if view != "" && view != "flat" && view != "tree" {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"view": []string{`must be "flat" or "tree"`}})
+			return
+		}
+
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		filter := conduit.CommentFilter{ArticleID: &article.ID}
+
+		if view != "tree" {
+			filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+			filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+		}
+
+		comments, err := s.commentService.Comments(r.Context(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		s.presentComments(r, comments...)
+
+		if view == "tree" {
+			comments = conduit.CommentTree(comments)
+		}
+
+		writeJSON(w, http.StatusOK, M{"comments": comments})
+	}
+}
+
+func (s *Server) deleteComment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		comment, ok := s.commentFromRequest(w, r, article)
+		if !ok {
+			return
+		}
+
+		if comment.IsDeleted() {
+			notFoundError(w)
+			return
+		}
+
+		if comment.AuthorID != userFromContext(r.Context()).ID {
+			forbiddenError(w)
+			return
+		}
+
+		if err := s.commentService.DeleteComment(r.Context(), comment); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+func (s *Server) addCommentReaction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, ok := reactionKind(w, mux.Vars(r)["kind"])
+		if !ok {
+			return
+		}
+
+		comment, ok := s.reactableComment(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		reactions, err := s.reactionService.AddReaction(ctx, comment.ReactionTarget(), userFromContext(ctx), kind)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		comment.Reactions = reactions
+
+		s.presentComments(r, comment)
+
+		writeJSON(w, http.StatusOK, M{"comment": comment})
+	}
+}
+
+func (s *Server) removeCommentReaction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, ok := reactionKind(w, mux.Vars(r)["kind"])
+		if !ok {
+			return
+		}
+
+		comment, ok := s.reactableComment(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		reactions, err := s.reactionService.RemoveReaction(ctx, comment.ReactionTarget(), userFromContext(ctx), kind)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		comment.Reactions = reactions
+
+		s.presentComments(r, comment)
+
+		writeJSON(w, http.StatusOK, M{"comment": comment})
+	}
+}
+
+func (s *Server) listCommentReactions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := conduit.ReactionFilter{}
+
+		if v := query.Get("kind"); v != "" {
+			kind, ok := reactionKind(w, v)
+			if !ok {
+				return
+			}
+			filter.Kind = &kind
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		comment, ok := s.reactableComment(w, r)
+		if !ok {
+			return
+		}
+
+		reactions, err := s.reactionService.Reactions(r.Context(), comment.ReactionTarget(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"reactions": reactions})
+	}
+}
+
+// reactableComment loads the comment named in the route from a visible
+// article. Deleted placeholders cannot be reacted to.
+func (s *Server) reactableComment(w http.ResponseWriter, r *http.Request) (*conduit.Comment, bool) {
+	article, ok := s.visibleArticle(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	comment, ok := s.commentFromRequest(w, r, article)
+	if !ok {
+		return nil, false
+	}
+
+	if comment.IsDeleted() {
+		notFoundError(w)
+		return nil, false
+	}
+
+	return comment, true
+}
+
+func (s *Server) commentFromRequest(w http.ResponseWriter, r *http.Request, article *conduit.Article) (*conduit.Comment, bool) {
+	id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+	comment, err := s.commentService.CommentByID(r.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, conduit.ErrNotFound):
+			notFoundError(w)
+		default:
+			serverError(w, err)
+		}
+		return nil, false
+	}
+
+	if comment.ArticleID != article.ID {
+		notFoundError(w)
+		return nil, false
+	}
+
+	return comment, true
+}
+
+func (s *Server) presentComments(r *http.Request, comments ...*conduit.Comment) {
+	user := userFromContext(r.Context())
+
+	for _, c := range comments {
+		c.SetAuthorProfile(user)
+
+		if c.Reactions == nil {
+			c.Reactions = conduit.NewReactions()
+		}
+	}
+
+	if user.IsAnonymous() {
+		return
+	}
+
+	targets := make([]conduit.ReactionTarget, len(comments))
+	for i, c := range comments {
+		targets[i] = c.ReactionTarget()
+	}
+
+	mine, err := s.reactionService.ViewerReactions(r.Context(), user, targets...)
+	if err != nil {
+		log.Printf("cannot load reactions of user %d: %v", user.ID, err)
+		return
+	}
+
+	for _, c := range comments {
+		c.Reactions.Mine = mine[c.ReactionTarget()]
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/digest"
+)
+
+// digestInterval is how often due digests are looked for.
+const digestInterval = time.Hour
+
+func (s *Server) getDigestPreference() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		pref, err := s.digestService.DigestPreference(ctx, userFromContext(ctx))
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"digest": pref})
+	}
+}
+
+func (s *Server) updateDigestPreference() http.HandlerFunc {
+	type Input struct {
+		Frequency string `json:"frequency" validate:"required,oneof=off daily weekly"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		ctx := r.Context()
+
+		pref, err := s.digestService.SetDigestFrequency(ctx, userFromContext(ctx), input.Frequency)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"digest": pref})
+	}
+}
+
+// unsubscribePage asks to confirm turning the digest off, then tells it is
+// done. Link scanners in mail clients follow links, so only the POST from
+// its form, or from a client offering one-click unsubscribe, turns it off.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Unsubscribe from the digest</title>
+</head>
+<body>
+{{- if .Done}}
+<p>You will no longer receive the digest email. You can turn it back on in your settings.</p>
+{{- else}}
+<form method="post" action="{{.Action}}">
+<p>Stop receiving the digest email?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+{{- end}}
+</body>
+</html>
+`))
+
+// confirmUnsubscribeDigest shows the page the unsubscribe link in the email
+// opens, without changing anything.
+func (s *Server) confirmUnsubscribeDigest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := digest.ParseUnsubscribeToken(hmacSampleSecret, r.URL.Query().Get("token")); !ok {
+			notFoundError(w)
+			return
+		}
+
+		writeUnsubscribePage(w, r, false)
+	}
+}
+
+// unsubscribeDigest turns the digest off for the user the signed link was
+// made for.
+func (s *Server) unsubscribeDigest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := digest.ParseUnsubscribeToken(hmacSampleSecret, r.URL.Query().Get("token"))
+		if !ok {
+			notFoundError(w)
+			return
+		}
+
+		if _, err := s.digestService.SetDigestFrequency(r.Context(), &conduit.User{ID: userID}, conduit.DigestOff); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeUnsubscribePage(w, r, true)
+	}
+}
+
+func writeUnsubscribePage(w http.ResponseWriter, r *http.Request, done bool) {
+	data := struct {
+		Action string
+		Done   bool
+	}{
+		Action: r.URL.RequestURI(),
+		Done:   done,
+	}
+
+	var buf bytes.Buffer
+	if err := unsubscribePage.Execute(&buf, data); err != nil {
+		serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
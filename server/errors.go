@@ -41,6 +41,14 @@ func badRequestError(w http.ResponseWriter) {
 	errorResponse(w, http.StatusUnprocessableEntity, "unable to process request")
 }
 
+func notFoundError(w http.ResponseWriter) {
+	errorResponse(w, http.StatusNotFound, "the requested resource could not be found")
+}
+
+func forbiddenError(w http.ResponseWriter) {
+	errorResponse(w, http.StatusForbidden, "you do not have permission to perform this action")
+}
+
 func invalidUserCredentialsError(w http.ResponseWriter) {
 	msg := "invalid authentication credentials"
 	errorResponse(w, http.StatusUnauthorized, msg)
@@ -74,5 +82,13 @@ func checkTagRules(e validator.FieldError) (errMsg string) {
 	if tag == "max" {
 		errMsg = fmt.Sprintf("%s must be less than %v", field, param)
 	}
+
+	if tag == "url" {
+		errMsg = fmt.Sprintf("%q is not a valid url", value)
+	}
+
+	if tag == "oneof" {
+		errMsg = fmt.Sprintf("%s must be one of %v", field, param)
+	}
 	return
 }
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+const (
+	// eventsReplayLimit caps how many missed events are replayed to a
+	// client resuming with Last-Event-ID.
+	eventsReplayLimit = 500
+
+	// eventsKeepAlive is how often a comment line is sent on an idle stream
+	// so proxies do not close it.
+	eventsKeepAlive = 30 * time.Second
+)
+
+// userEvents streams the current user's events as Server-Sent Events. A
+// client reconnecting with Last-Event-ID first receives what it missed. The
+// stream ends when the hub cannot tell which events it missed, for the
+// client to reconnect and resume.
+func (s *Server) userEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			serverError(w, fmt.Errorf("streaming unsupported by %T", w))
+			return
+		}
+
+		ctx := r.Context()
+		user := userFromContext(ctx)
+
+		// subscribe before replaying so nothing stored meanwhile is lost
+		events, cancel := s.hub.subscribe(user.ID)
+		defer cancel()
+
+		var lastID uint64
+		if v := r.Header.Get("Last-Event-ID"); v != "" {
+			lastID, _ = strconv.ParseUint(v, 10, 64)
+		}
+
+		var missed []*conduit.Event
+		if lastID > 0 {
+			var err error
+			if missed, err = s.eventService.EventsSince(ctx, user, lastID, eventsReplayLimit); err != nil {
+				serverError(w, err)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		// events stored while replaying may come through the hub too
+		replayed := make(map[uint64]bool, len(missed))
+		for _, e := range missed {
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+			replayed[e.ID] = true
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.shutdown:
+				return
+			case e, ok := <-events:
+				if !ok {
+					return // events were missed: let the client resume
+				}
+
+				if replayed[e.ID] {
+					continue
+				}
+
+				if err := writeEvent(w, e); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e *conduit.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, e.Data)
+	return err
+}
+
+// relayEvents feeds the hub from the event service until ctx is done,
+// reconnecting when the listener fails.
+func (s *Server) relayEvents(ctx context.Context) {
+	go relay(ctx, func() error { return s.eventService.ListenComments(ctx, s.hub.publishComment) })
+	relay(ctx, func() error { return s.eventService.Listen(ctx, s.hub) })
+}
+
+func relay(ctx context.Context, listen func() error) {
+	for {
+		err := listen()
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("cannot listen for events: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
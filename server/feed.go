@@ -0,0 +1,213 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/feed"
+)
+
+// Feeds list the newest articles. ?limit= may ask for up to maxFeedEntries.
+const (
+	defaultFeedEntries = 20
+	maxFeedEntries     = 100
+)
+
+// articleFeedSource loads the articles of a feed, given the filter of the
+// request, and titles it.
+type articleFeedSource func(r *http.Request, filter conduit.ArticleFilter) (string, []*conduit.Article, error)
+
+func (s *Server) articlesFeed() http.HandlerFunc {
+	return s.serveArticleFeed("public", func(r *http.Request, filter conduit.ArticleFilter) (string, []*conduit.Article, error) {
+		articles, err := s.articleService.Articles(r.Context(), filter)
+		return "Conduit: latest articles", articles, err
+	})
+}
+
+func (s *Server) authorFeed() http.HandlerFunc {
+	return s.serveArticleFeed("public", func(r *http.Request, filter conduit.ArticleFilter) (string, []*conduit.Article, error) {
+		user, err := s.userService.UserByUsername(r.Context(), mux.Vars(r)["username"])
+		if err != nil {
+			return "", nil, err
+		}
+
+		filter.AuthorUsername = &user.Username
+		articles, err := s.articleService.Articles(r.Context(), filter)
+		return "Conduit: articles by " + user.Username, articles, err
+	})
+}
+
+func (s *Server) tagFeed() http.HandlerFunc {
+	return s.serveArticleFeed("public", func(r *http.Request, filter conduit.ArticleFilter) (string, []*conduit.Article, error) {
+		tag := mux.Vars(r)["tag"]
+		filter.Tag = &tag
+		articles, err := s.articleService.Articles(r.Context(), filter)
+		return "Conduit: articles tagged " + tag, articles, err
+	})
+}
+
+// serveArticleFeed answers a feed of the articles source loads for the
+// query, which the JSON listings would return too. The format is the
+// {format} extension. ?content=full carries the article bodies instead of
+// their descriptions. cacheControl tells shared caches whether they may keep
+// the feed.
+func (s *Server) serveArticleFeed(cacheControl string, source articleFeedSource) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, ok := articleFilterFromQuery(w, r)
+		if !ok {
+			return
+		}
+
+		if filter.Limit <= 0 {
+			filter.Limit = defaultFeedEntries
+		} else if filter.Limit > maxFeedEntries {
+			filter.Limit = maxFeedEntries
+		}
+
+		title, articles, err := source(r, filter)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		full := r.URL.Query().Get("content") == "full"
+		format := mux.Vars(r)["format"]
+
+		f := s.articleFeedOf(r, title, articles, full)
+
+		w.Header().Set("ETag", feedETag(r.URL, articles))
+		w.Header().Set("Cache-Control", cacheControl+", max-age=300")
+
+		if notModified(r, w.Header().Get("ETag")) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		var buf bytes.Buffer
+		write, contentType := feed.WriteAtom, feed.AtomContentType
+		if format == "rss" {
+			write, contentType = feed.WriteRSS, feed.RSSContentType
+		}
+
+		if err := write(&buf, f); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+}
+
+func (s *Server) articleFeedOf(r *http.Request, title string, articles []*conduit.Article, full bool) *feed.Feed {
+	base := s.absoluteURL(r, "")
+
+	f := feed.Feed{
+		ID:    s.absoluteURL(r, r.URL.Path),
+		Title: title,
+		Link:  base + "/",
+		Self:  s.absoluteURL(r, r.URL.RequestURI()),
+	}
+
+	for _, a := range articles {
+		entry := feed.Entry{
+			ID:        feedTagURI(base, a.CreatedAt, "articles/"+strconv.FormatUint(uint64(a.ID), 10)),
+			Title:     a.Title,
+			Link:      s.articleURL(r, a),
+			Author:    a.Author.Username,
+			Summary:   a.Description,
+			Published: a.CreatedAt,
+			Updated:   a.UpdatedAt,
+		}
+
+		if a.PublishedAt != nil {
+			entry.Published = *a.PublishedAt
+		}
+
+		if full {
+			entry.Content = a.BodyHTML
+		}
+
+		for _, t := range a.Tags {
+			entry.Categories = append(entry.Categories, t.Name)
+		}
+
+		if a.UpdatedAt.After(f.Updated) {
+			f.Updated = a.UpdatedAt
+		}
+
+		f.Entries = append(f.Entries, &entry)
+	}
+
+	return &f
+}
+
+// feedTagURI makes a tag URI (RFC 4151) for the specific path of the site.
+// Article GUIDs are dated with the article's creation and keyed by its ID so
+// that they survive title and slug changes.
+func feedTagURI(base string, date time.Time, specific string) string {
+	host := base
+	if u, err := url.Parse(base); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+
+	return fmt.Sprintf("tag:%s,%s:%s", host, date.UTC().Format("2006-01-02"), strings.TrimPrefix(specific, "/"))
+}
+
+// feedETag changes whenever an entry is added, removed or updated, or the
+// query asks for a different feed.
+func feedETag(u *url.URL, articles []*conduit.Article) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s?%s\n", u.Path, u.RawQuery)
+
+	for _, a := range articles {
+		fmt.Fprintf(h, "%d %d\n", a.ID, a.UpdatedAt.UnixNano())
+	}
+
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// notModified evaluates If-None-Match. Feeds send no Last-Modified: the
+// latest update of their entries does not change when an entry drops out,
+// so only the ETag tells whether a feed changed.
+func notModified(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate != "" && (candidate == etag || candidate == "*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// absoluteURL prefixes path with the configured base URL, or with the
+// scheme and host the request came in on.
+func (s *Server) absoluteURL(r *http.Request, path string) string {
+	if s.baseURL != "" {
+		return s.baseURL + path
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host + path
+}
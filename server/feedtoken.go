@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) getFeedToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token, err := s.feedTokenService.FeedToken(ctx, userFromContext(ctx))
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"feedToken": token})
+	}
+}
+
+// rotateFeedToken generates the user's feed token, replacing the previous
+// one. The token and the feed URL are only shown in this response.
+func (s *Server) rotateFeedToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		secret, err := randomToken()
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		token, err := s.feedTokenService.SetFeedToken(ctx, userFromContext(ctx), secret)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		feedURL := s.absoluteURL(r, "/feeds/me/"+token.Token+".atom")
+
+		writeJSON(w, http.StatusCreated, M{"feedToken": token, "feedUrl": feedURL})
+	}
+}
+
+func (s *Server) revokeFeedToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if err := s.feedTokenService.RevokeFeedToken(ctx, userFromContext(ctx)); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+// personalFeed is the feed of articleFeed for the user whose token is in the
+// URL. The token only ever authenticates this feed. ?source= narrows it as
+// it does articleFeed.
+func (s *Server) personalFeed() http.HandlerFunc {
+	serve := s.serveArticleFeed("private", func(r *http.Request, filter conduit.ArticleFilter) (string, []*conduit.Article, error) {
+		ctx := r.Context()
+
+		user, err := s.feedTokenService.UserByFeedToken(ctx, mux.Vars(r)["token"])
+		if err != nil {
+			return "", nil, err
+		}
+
+		feedFilter := conduit.ArticleFilter{
+			FeedSource: r.URL.Query().Get("source"),
+			Limit:      filter.Limit,
+			Offset:     filter.Offset,
+		}
+
+		articles, err := s.articleService.ArticleFeed(ctx, user, feedFilter)
+		return "Conduit: your feed, " + user.Username, articles, err
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := feedSourceFromQuery(w, r); !ok {
+			return
+		}
+
+		serve(w, r)
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// subscriberBuffer is how many events may queue up for a slow subscriber
+// before its stream is closed, or further comment events are dropped for it.
+const subscriberBuffer = 32
+
+// hub fans events out to the streams open on this instance: user events by
+// user and comment events by article. It is fed by the event service, which
+// sees the events of every instance.
+type hub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan *conduit.Event]bool
+	watchers    map[uint]map[chan *conduit.CommentEvent]bool
+}
+
+func newHub() *hub {
+	return &hub{
+		subscribers: make(map[uint]map[chan *conduit.Event]bool),
+		watchers:    make(map[uint]map[chan *conduit.CommentEvent]bool),
+	}
+}
+
+// subscribe returns a channel receiving the user's events until cancel is
+// called. The hub closes the channel when events may have been missed, so
+// the stream ends and its client resumes with Last-Event-ID.
+func (h *hub) subscribe(userID uint) (events <-chan *conduit.Event, cancel func()) {
+	ch := make(chan *conduit.Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan *conduit.Event]bool)
+	}
+	h.subscribers[userID][ch] = true
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		h.unsubscribe(userID, ch)
+		h.mu.Unlock()
+	}
+}
+
+var _ conduit.EventSink = (*hub)(nil)
+
+func (h *hub) Wants(userID uint) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscribers[userID]) > 0
+}
+
+// Publish never blocks: a subscriber that fell behind is closed rather than
+// silently skipping the event.
+func (h *hub) Publish(event *conduit.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			h.unsubscribe(event.UserID, ch)
+		}
+	}
+}
+
+// Missed closes every subscriber, as the events they miss cannot be told.
+func (h *hub) Missed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for userID, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			h.unsubscribe(userID, ch)
+		}
+	}
+}
+
+// unsubscribe closes ch and forgets it. h.mu must be held.
+func (h *hub) unsubscribe(userID uint, ch chan *conduit.Event) {
+	if !h.subscribers[userID][ch] {
+		return
+	}
+
+	close(ch)
+
+	delete(h.subscribers[userID], ch)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
+
+// watch returns a channel receiving the comment events of the article until
+// cancel is called.
+func (h *hub) watch(articleID uint) (events <-chan *conduit.CommentEvent, cancel func()) {
+	ch := make(chan *conduit.CommentEvent, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.watchers[articleID] == nil {
+		h.watchers[articleID] = make(map[chan *conduit.CommentEvent]bool)
+	}
+	h.watchers[articleID][ch] = true
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.watchers[articleID], ch)
+		if len(h.watchers[articleID]) == 0 {
+			delete(h.watchers, articleID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// publishComment never blocks: watchers that fell behind miss the event.
+func (h *hub) publishComment(event *conduit.CommentEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.watchers[event.ArticleID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+const (
+	liveWriteWait      = 10 * time.Second
+	livePongWait       = 60 * time.Second
+	livePingPeriod     = livePongWait * 9 / 10
+	liveMaxMessageSize = 16 << 10
+
+	// a connection may post liveCommentBurst comments at once, then one
+	// every liveCommentInterval
+	liveCommentBurst    = 5
+	liveCommentInterval = 10 * time.Second
+)
+
+// Tokens authenticate live connections, not cookies, so any origin may
+// connect.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+type liveMessage struct {
+	Type     string `json:"type"`
+	Body     string `json:"body"`
+	ParentID *uint  `json:"parentId"`
+}
+
+type liveError struct {
+	Type   string `json:"type"`
+	Errors ErrorM `json:"errors"`
+}
+
+func newLiveError(field, message string) *liveError {
+	return &liveError{Type: "error", Errors: ErrorM{field: []string{message}}}
+}
+
+// liveComments upgrades to a WebSocket that receives the comments created
+// and deleted on the article. Authenticated users may also post comments by
+// sending {"type": "comment", "body": ..., "parentId": ...}.
+func (s *Server) liveComments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		if !s.trackLive() {
+			errorResponse(w, http.StatusServiceUnavailable, "server shutting down")
+			return
+		}
+
+		defer s.live.Done()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return // the upgrader already answered
+		}
+
+		defer conn.Close()
+
+		user := userFromContext(r.Context())
+
+		events, cancel := s.hub.watch(article.ID)
+		defer cancel()
+
+		replies := make(chan interface{}, 1)
+		readerDone, writerDone := make(chan struct{}), make(chan struct{})
+		defer close(writerDone)
+
+		go s.readLiveComments(r, conn, article, replies, readerDone, writerDone)
+
+		ping := time.NewTicker(livePingPeriod)
+		defer ping.Stop()
+
+		for {
+			var message interface{}
+
+			select {
+			case <-readerDone:
+				return
+			case <-s.shutdown:
+				closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(liveWriteWait))
+				return
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteWait)); err != nil {
+					return
+				}
+				continue
+			case e := <-events:
+				if e.Comment != nil {
+					comment := *e.Comment
+					comment.SetAuthorProfile(user)
+					e = &conduit.CommentEvent{Kind: e.Kind, ArticleID: e.ArticleID, CommentID: e.CommentID, Comment: &comment}
+				}
+				message = e
+			case message = <-replies:
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := conn.WriteJSON(message); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLiveComments handles what the client sends until the connection
+// fails, then closes readerDone. Replies go back through the writer.
+func (s *Server) readLiveComments(r *http.Request, conn *websocket.Conn, article *conduit.Article, replies chan<- interface{}, readerDone chan<- struct{}, writerDone <-chan struct{}) {
+	defer close(readerDone)
+
+	ctx := r.Context()
+	user := userFromContext(ctx)
+	limiter := newTokenBucket(liveCommentBurst, liveCommentInterval)
+
+	conn.SetReadLimit(liveMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+
+	for {
+		var message liveMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("live comments on article %d: %v", article.ID, err)
+			}
+			return
+		}
+
+		if failure := s.postLiveComment(ctx, user, article, message, limiter); failure != nil {
+			select {
+			case replies <- failure:
+			case <-writerDone:
+				return
+			}
+		}
+	}
+}
+
+// postLiveComment stores the comment a client sent, or returns why it
+// cannot.
+func (s *Server) postLiveComment(ctx context.Context, user *conduit.User, article *conduit.Article, message liveMessage, limiter *tokenBucket) *liveError {
+	switch {
+	case message.Type != "comment":
+		return newLiveError("type", `must be "comment"`)
+	case user.IsAnonymous():
+		return newLiveError("user", "must be logged in to comment")
+	case message.Body == "":
+		return newLiveError("body", "this field is required")
+	case !limiter.allow(time.Now()):
+		return newLiveError("body", "too many comments, slow down")
+	}
+
+	comment := conduit.Comment{
+		ArticleID: article.ID,
+		ParentID:  message.ParentID,
+		Body:      message.Body,
+		Author:    user,
+	}
+
+	// the new comment reaches this connection through the hub
+	if err := s.commentService.CreateComment(ctx, &comment); err != nil {
+		switch {
+		case errors.Is(err, conduit.ErrNotFound):
+			return newLiveError("parentId", "comment does not exist")
+		case errors.Is(err, conduit.ErrCommentTooDeep):
+			return newLiveError("parentId", "replies cannot nest any deeper")
+		default:
+			log.Printf("live comments on article %d: %v", article.ID, err)
+			return newLiveError("server", "cannot post comment")
+		}
+	}
+
+	return nil
+}
+
+// tokenFromQuery lets clients that cannot set headers, such as browser
+// WebSockets, pass their token as ?token= to authenticate.
+func tokenFromQuery(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Token "+token)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
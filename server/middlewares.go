@@ -4,6 +4,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
@@ -11,10 +12,51 @@ import (
 
 func Logger(w io.Writer) func(h http.Handler) http.Handler {
 	return (func(h http.Handler) http.Handler {
-		return handlers.LoggingHandler(w, h)
+		logged := handlers.LoggingHandler(w, h)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logged.ServeHTTP(w, redactToken(r))
+		})
 	})
 }
 
+// personalFeedPrefix starts the path of the personal feeds, whose next
+// segment is the user's feed token. See personalFeed.
+const personalFeedPrefix = "/feeds/me/"
+
+// redactToken hides the tokens that authenticate a request without an
+// Authorization header, a ?token= query parameter (see tokenFromQuery) and
+// the feed token of a personal feed URL, from the request line written to
+// the log. Handlers still read them from r.URL.
+func redactToken(r *http.Request) *http.Request {
+	u := *r.URL
+	redacted := false
+
+	if query := u.Query(); query.Get("token") != "" {
+		query.Set("token", "REDACTED")
+		u.RawQuery = query.Encode()
+		redacted = true
+	}
+
+	if strings.HasPrefix(u.Path, personalFeedPrefix) {
+		name := strings.TrimPrefix(u.Path, personalFeedPrefix)
+		if i := strings.Index(name, "."); i > 0 {
+			u.Path = personalFeedPrefix + "REDACTED" + name[i:]
+			u.RawPath = ""
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return r
+	}
+
+	r = r.WithContext(r.Context())
+	r.RequestURI = u.RequestURI()
+
+	return r
+}
+
 func (s *Server) authenticate(mustAuth bool) func(http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -61,3 +103,23 @@ func (s *Server) authenticate(mustAuth bool) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// writeTimeout answers 503 when a request takes longer than d. Streaming
+// routes must not use it, as it buffers the whole response.
+func writeTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.TimeoutHandler(h, d, `{"errors":{"server":["request timed out"]}}`)
+	}
+}
+
+// requireModerator must run after authenticate(MustAuth).
+func (s *Server) requireModerator(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !userFromContext(r.Context()).IsModerator() {
+			forbiddenError(w)
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// listNotifications returns the user's inbox, newest first. ?unread=true
+// leaves out what was already read.
+func (s *Server) listNotifications() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		user := userFromContext(ctx)
+		filter := conduit.NotificationFilter{}
+
+		filter.UnreadOnly, _ = strconv.ParseBool(query.Get("unread"))
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		notifications, err := s.notificationService.Notifications(ctx, user, filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		unread, err := s.notificationService.UnreadNotificationCount(ctx, user)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"notifications": notifications, "unreadCount": unread})
+	}
+}
+
+func (s *Server) markNotificationRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+
+		if err := s.notificationService.MarkNotificationRead(ctx, userFromContext(ctx), uint(id)); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+func (s *Server) markAllNotificationsRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		if err := s.notificationService.MarkAllNotificationsRead(ctx, userFromContext(ctx)); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+const providerName = "Conduit"
+
+// articlePage is all a link preview needs: Open Graph and Twitter card tags,
+// the oEmbed discovery link and a short fallback body.
+var articlePage = template.Must(template.New("article").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Article.Title}}</title>
+<meta name="description" content="{{.Article.Description}}">
+<link rel="canonical" href="{{.URL}}">
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}" title="{{.Article.Title}}">
+<meta property="og:type" content="article">
+<meta property="og:site_name" content="{{.Provider}}">
+<meta property="og:title" content="{{.Article.Title}}">
+<meta property="og:description" content="{{.Article.Description}}">
+<meta property="og:url" content="{{.URL}}">
+{{- if .Image}}
+<meta property="og:image" content="{{.Image}}">
+{{- end}}
+{{- with .Article.PublishedAt}}
+<meta property="article:published_time" content="{{.UTC.Format "2006-01-02T15:04:05Z07:00"}}">
+{{- end}}
+<meta property="article:modified_time" content="{{.Article.UpdatedAt.UTC.Format "2006-01-02T15:04:05Z07:00"}}">
+<meta property="article:author" content="{{.AuthorURL}}">
+{{- range .Article.Tags}}
+<meta property="article:tag" content="{{.Name}}">
+{{- end}}
+<meta name="twitter:card" content="summary">
+<meta name="twitter:title" content="{{.Article.Title}}">
+<meta name="twitter:description" content="{{.Article.Description}}">
+{{- if .Image}}
+<meta name="twitter:image" content="{{.Image}}">
+{{- end}}
+</head>
+<body>
+<h1>{{.Article.Title}}</h1>
+<p>by <a href="{{.AuthorURL}}">{{.Article.Author.Username}}</a></p>
+<p>{{.Article.Description}}</p>
+</body>
+</html>
+`))
+
+type oEmbed struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	AuthorURL    string `json:"author_url"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	CacheAge     int    `json:"cache_age"`
+}
+
+// articleMeta serves the page an article's URL points at, for chat tools
+// and crawlers building a preview of it.
+func (s *Server) articleMeta() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := mux.Vars(r)["slug"]
+
+		article, err := s.publishedArticle(r, slug)
+		if err != nil {
+			if !errors.Is(err, conduit.ErrNotFound) {
+				serverError(w, err)
+				return
+			}
+
+			current, err := s.articleService.CurrentSlug(r.Context(), slug)
+			switch {
+			case err == nil:
+				http.Redirect(w, r, slugRedirectPath(r, current), http.StatusMovedPermanently)
+			case errors.Is(err, conduit.ErrNotFound):
+				http.NotFound(w, r)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		pageURL := s.articleURL(r, article)
+		oembedURL := s.absoluteURL(r, "/oembed?url="+url.QueryEscape(pageURL))
+
+		data := struct {
+			Article   *conduit.Article
+			Provider  string
+			URL       string
+			OEmbedURL string
+			AuthorURL string
+			Image     string
+		}{
+			Article:   article,
+			Provider:  providerName,
+			URL:       pageURL,
+			OEmbedURL: oembedURL,
+			AuthorURL: s.profileURL(r, article.Author.Username),
+			Image:     article.Author.Image,
+		}
+
+		var buf bytes.Buffer
+		if err := articlePage.Execute(&buf, data); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		w.Header().Set("Link", oEmbedLink(oembedURL, article.Title))
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+}
+
+// oembed answers the oEmbed request for the URL of a published article.
+// Only the JSON format is offered; other URLs are not found.
+func (s *Server) oembed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		if v := query.Get("format"); v != "" && v != "json" {
+			errorResponse(w, http.StatusNotImplemented, ErrorM{"format": []string{"must be json"}})
+			return
+		}
+
+		slug, ok := s.articleSlugOf(r, query.Get("url"))
+		if !ok {
+			notFoundError(w)
+			return
+		}
+
+		article, err := s.publishedArticle(r, slug)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		resp := oEmbed{
+			Type:         "link",
+			Version:      "1.0",
+			Title:        article.Title,
+			AuthorName:   article.Author.Username,
+			AuthorURL:    s.profileURL(r, article.Author.Username),
+			ProviderName: providerName,
+			ProviderURL:  s.absoluteURL(r, "/"),
+			CacheAge:     300,
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// articleSlugOf returns the slug of an article URL of this site.
+func (s *Server) articleSlugOf(r *http.Request, raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	site, err := url.Parse(s.absoluteURL(r, "/"))
+	if err != nil || !strings.EqualFold(u.Host, site.Host) {
+		return "", false
+	}
+
+	slug := strings.TrimSuffix(strings.TrimPrefix(u.Path, "/articles/"), "/")
+	if slug == "" || slug == u.Path || strings.Contains(slug, "/") {
+		return "", false
+	}
+
+	return slug, true
+}
+
+// publishedArticle is the article under slug, hiding those not public yet.
+func (s *Server) publishedArticle(r *http.Request, slug string) (*conduit.Article, error) {
+	article, err := s.articleService.ArticleBySlug(r.Context(), slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if !article.IsPublished() {
+		return nil, conduit.ErrNotFound
+	}
+
+	return article, nil
+}
+
+func (s *Server) articleURL(r *http.Request, article *conduit.Article) string {
+	return s.absoluteURL(r, "/articles/"+url.PathEscape(article.Slug))
+}
+
+func (s *Server) profileURL(r *http.Request, username string) string {
+	return s.absoluteURL(r, "/profiles/"+url.PathEscape(username))
+}
+
+func oEmbedLink(href, title string) string {
+	return `<` + href + `>; rel="alternate"; type="application/json+oembed"; title="` + strings.ReplaceAll(title, `"`, `'`) + `"`
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) followUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.otherUser(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		me := userFromContext(ctx)
+
+		if err := s.userService.FollowUser(ctx, me, user); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"profile": profileOf(user, me)})
+	}
+}
+
+func (s *Server) unfollowUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.otherUser(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		me := userFromContext(ctx)
+
+		if err := s.userService.UnfollowUser(ctx, me, user); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"profile": profileOf(user, me)})
+	}
+}
+
+func (s *Server) blockUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.otherUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.userService.BlockUser(r.Context(), userFromContext(r.Context()), user); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+func (s *Server) unblockUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.otherUser(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.userService.UnblockUser(r.Context(), userFromContext(r.Context()), user); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+// otherUser loads the user named in the route, who must not be the current
+// user. On failure the error response is written and ok is false.
+func (s *Server) otherUser(w http.ResponseWriter, r *http.Request) (*conduit.User, bool) {
+	ctx := r.Context()
+
+	user, err := s.userService.UserByUsername(ctx, mux.Vars(r)["username"])
+	if err != nil {
+		switch {
+		case errors.Is(err, conduit.ErrNotFound):
+			notFoundError(w)
+		default:
+			serverError(w, err)
+		}
+		return nil, false
+	}
+
+	if user.ID == userFromContext(ctx).ID {
+		errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"username": []string{"cannot be yourself"}})
+		return nil, false
+	}
+
+	return user, true
+}
+
+func profileOf(user, currentUser *conduit.User) *conduit.Profile {
+	return &conduit.Profile{
+		Username:  user.Username,
+		Bio:       user.Bio,
+		Image:     user.Image,
+		Following: currentUser.IsFollowing(user),
+	}
+}
+
+// profilePage is the page a profile URL points at: Open Graph tags for link
+// previews, the author's feed and their latest articles for crawlers.
+var profilePage = template.Must(template.New("profile").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.User.Username}}</title>
+<meta name="description" content="{{.User.Bio}}">
+<link rel="canonical" href="{{.URL}}">
+<link rel="alternate" type="application/atom+xml" href="{{.FeedURL}}" title="Articles by {{.User.Username}}">
+<meta property="og:type" content="profile">
+<meta property="og:site_name" content="{{.Provider}}">
+<meta property="og:title" content="{{.User.Username}}">
+<meta property="og:description" content="{{.User.Bio}}">
+<meta property="og:url" content="{{.URL}}">
+<meta property="profile:username" content="{{.User.Username}}">
+{{- if .User.Image}}
+<meta property="og:image" content="{{.User.Image}}">
+{{- end}}
+<meta name="twitter:card" content="summary">
+<meta name="twitter:title" content="{{.User.Username}}">
+<meta name="twitter:description" content="{{.User.Bio}}">
+</head>
+<body>
+<h1>{{.User.Username}}</h1>
+<p>{{.User.Bio}}</p>
+<ul>
+{{- range .Articles}}
+<li><a href="{{.URL}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
+
+// profileArticles is how many of the latest articles a profile page lists.
+const profileArticles = 10
+
+// profileMeta serves the page a profile's URL points at, the author URL of
+// article previews and sitemap entries.
+func (s *Server) profileMeta() http.HandlerFunc {
+	type link struct {
+		Title string
+		URL   string
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		user, err := s.userService.UserByUsername(ctx, mux.Vars(r)["username"])
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				http.NotFound(w, r)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		articles, err := s.articleService.Articles(ctx, conduit.ArticleFilter{
+			AuthorUsername: &user.Username,
+			Limit:          profileArticles,
+		})
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		links := make([]link, len(articles))
+		for i, a := range articles {
+			links[i] = link{Title: a.Title, URL: s.articleURL(r, a)}
+		}
+
+		data := struct {
+			User     *conduit.User
+			Provider string
+			URL      string
+			FeedURL  string
+			Articles []link
+		}{
+			User:     user,
+			Provider: providerName,
+			URL:      s.profileURL(r, user.Username),
+			FeedURL:  s.absoluteURL(r, "/feeds/authors/"+url.PathEscape(user.Username)+".atom"),
+			Articles: links,
+		}
+
+		var buf bytes.Buffer
+		if err := profilePage.Execute(&buf, data); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import "time"
+
+// tokenBucket allows bursts of up to size actions and refills one token every
+// interval. It is not safe for concurrent use.
+type tokenBucket struct {
+	size     float64
+	tokens   float64
+	interval time.Duration
+	last     time.Time
+}
+
+func newTokenBucket(size int, interval time.Duration) *tokenBucket {
+	return &tokenBucket{size: float64(size), tokens: float64(size), interval: interval}
+}
+
+// allow takes a token if one is left at now.
+func (b *tokenBucket) allow(now time.Time) bool {
+	if !b.last.IsZero() {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > b.size {
+			b.tokens = b.size
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
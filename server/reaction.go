@@ -0,0 +1,117 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) addReaction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, ok := reactionKind(w, mux.Vars(r)["kind"])
+		if !ok {
+			return
+		}
+
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		reactions, err := s.reactionService.AddReaction(ctx, article.ReactionTarget(), userFromContext(ctx), kind)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		article.Reactions = reactions
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+func (s *Server) removeReaction() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kind, ok := reactionKind(w, mux.Vars(r)["kind"])
+		if !ok {
+			return
+		}
+
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+
+		reactions, err := s.reactionService.RemoveReaction(ctx, article.ReactionTarget(), userFromContext(ctx), kind)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrNotFound):
+				notFoundError(w)
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		article.Reactions = reactions
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+// listReactions lists who reacted to the article, newest first, optionally
+// only for one ?kind.
+func (s *Server) listReactions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := conduit.ReactionFilter{}
+
+		if v := query.Get("kind"); v != "" {
+			kind, ok := reactionKind(w, v)
+			if !ok {
+				return
+			}
+			filter.Kind = &kind
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		article, ok := s.visibleArticle(w, r)
+		if !ok {
+			return
+		}
+
+		reactions, err := s.reactionService.Reactions(r.Context(), article.ReactionTarget(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"reactions": reactions})
+	}
+}
+
+// reactionKind checks kind is one of the supported reactions. On failure the
+// error response is written and ok is false.
+func reactionKind(w http.ResponseWriter, kind string) (string, bool) {
+	if !conduit.IsReactionKind(kind) {
+		message := "must be one of " + strings.Join(conduit.ReactionKinds, ", ")
+		errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"kind": []string{message}})
+		return "", false
+	}
+
+	return kind, true
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) listRevisions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.editableArticle(w, r)
+		if !ok {
+			return
+		}
+
+		revisions, err := s.articleService.ArticleRevisions(r.Context(), article)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"revisions": revisions})
+	}
+}
+
+func (s *Server) getRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.editableArticle(w, r)
+		if !ok {
+			return
+		}
+
+		number, _ := strconv.Atoi(mux.Vars(r)["revision"])
+
+		revision, ok := s.revision(w, r, article, number)
+		if !ok {
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"revision": revision})
+	}
+}
+
+func (s *Server) diffRevisions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		from, err := strconv.Atoi(query.Get("from"))
+		if err != nil {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"from": []string{"must be a revision number"}})
+			return
+		}
+
+		to, err := strconv.Atoi(query.Get("to"))
+		if err != nil {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"to": []string{"must be a revision number"}})
+			return
+		}
+
+		article, ok := s.editableArticle(w, r)
+		if !ok {
+			return
+		}
+
+		fromRevision, ok := s.revision(w, r, article, from)
+		if !ok {
+			return
+		}
+
+		toRevision, ok := s.revision(w, r, article, to)
+		if !ok {
+			return
+		}
+
+		diff, err := conduit.UnifiedDiff(
+			fmt.Sprintf("%s@%d", article.Slug, from),
+			fmt.Sprintf("%s@%d", article.Slug, to),
+			fromRevision.Text(),
+			toRevision.Text(),
+		)
+		if err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrDiffTooLarge):
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"revision": []string{"revisions too large to diff"}})
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"from": from, "to": to, "diff": diff})
+	}
+}
+
+func (s *Server) restoreRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, ok := s.editableArticle(w, r)
+		if !ok {
+			return
+		}
+
+		number, _ := strconv.Atoi(mux.Vars(r)["revision"])
+
+		revision, ok := s.revision(w, r, article, number)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		user := userFromContext(ctx)
+
+		patch := revision.Patch()
+		patch.Editor = user
+
+		if err := s.renderPatch(article, &patch); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		if err := s.articleService.UpdateArticle(ctx, article, patch); err != nil {
+			articleWriteError(w, err)
+			return
+		}
+
+		s.indexArticle(ctx, article)
+
+		s.presentArticles(r, article)
+
+		writeJSON(w, http.StatusOK, M{"article": article})
+	}
+}
+
+// editableArticle loads the article named in the route and checks the current
+// user may edit it. On failure the error response is written and ok is false.
+func (s *Server) editableArticle(w http.ResponseWriter, r *http.Request) (article *conduit.Article, ok bool) {
+	article, ok = s.articleFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !article.CanEdit(userFromContext(r.Context())) {
+		forbiddenError(w)
+		return nil, false
+	}
+
+	return article, true
+}
+
+// ownedArticle is like editableArticle but only lets the owner through.
+func (s *Server) ownedArticle(w http.ResponseWriter, r *http.Request) (article *conduit.Article, ok bool) {
+	article, ok = s.articleFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !article.IsOwnedBy(userFromContext(r.Context())) {
+		forbiddenError(w)
+		return nil, false
+	}
+
+	return article, true
+}
+
+// visibleArticle is like editableArticle but lets everyone through once the
+// article is published. Unpublished articles look missing to other users.
+func (s *Server) visibleArticle(w http.ResponseWriter, r *http.Request) (article *conduit.Article, ok bool) {
+	article, ok = s.articleFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if !article.IsPublished() && !article.CanEdit(userFromContext(r.Context())) {
+		notFoundError(w)
+		return nil, false
+	}
+
+	return article, true
+}
+
+func (s *Server) articleFromRequest(w http.ResponseWriter, r *http.Request) (*conduit.Article, bool) {
+	article, err := s.articleService.ArticleBySlug(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		switch {
+		case errors.Is(err, conduit.ErrNotFound):
+			notFoundError(w)
+		default:
+			serverError(w, err)
+		}
+		return nil, false
+	}
+
+	return article, true
+}
+
+func (s *Server) revision(w http.ResponseWriter, r *http.Request, article *conduit.Article, number int) (*conduit.ArticleRevision, bool) {
+	revision, err := s.articleService.ArticleRevision(r.Context(), article, number)
+	if err != nil {
+		switch {
+		case errors.Is(err, conduit.ErrNotFound):
+			notFoundError(w)
+		default:
+			serverError(w, err)
+		}
+		return nil, false
+	}
+
+	return revision, true
+}
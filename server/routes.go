@@ -1,27 +1,157 @@
 package server
 
-import "os"
+import (
+	"os"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
 
 const MustAuth bool = true
 
+const requestTimeout = 5 * time.Second
+
 func (s *Server) routes() {
 	s.router.Use(Logger(os.Stdout))
 	apiRouter := s.router.PathPrefix("/api/v1").Subrouter()
 
+	seoRoutes := s.router.PathPrefix("").Subrouter()
+	seoRoutes.Use(writeTimeout(requestTimeout))
+	{
+		seoRoutes.Handle("/robots.txt", s.robotsTxt()).Methods("GET", "HEAD")
+		seoRoutes.Handle("/sitemap.xml", s.sitemapIndex()).Methods("GET", "HEAD")
+		seoRoutes.Handle("/sitemaps/{page:[0-9]+}.xml", s.sitemapPage()).Methods("GET", "HEAD")
+		seoRoutes.Handle("/oembed", s.oembed()).Methods("GET", "HEAD")
+		seoRoutes.Handle("/articles/{slug}", s.articleMeta()).Methods("GET", "HEAD")
+		seoRoutes.Handle("/profiles/{username}", s.profileMeta()).Methods("GET", "HEAD")
+	}
+
+	// feeds live outside the API so readers get short, stable URLs
+	feedRoutes := s.router.PathPrefix("/feeds").Subrouter()
+	feedRoutes.Use(writeTimeout(requestTimeout))
+	{
+		feedRoutes.Handle("/articles.{format:atom|rss}", s.articlesFeed()).Methods("GET", "HEAD")
+		feedRoutes.Handle("/authors/{username}.{format:atom|rss}", s.authorFeed()).Methods("GET", "HEAD")
+		feedRoutes.Handle("/tags/{tag}.{format:atom|rss}", s.tagFeed()).Methods("GET", "HEAD")
+		feedRoutes.Handle("/me/{token:[0-9a-f]+}.{format:atom|rss}", s.personalFeed()).Methods("GET", "HEAD")
+	}
+
 	noAuth := apiRouter.PathPrefix("").Subrouter()
+	noAuth.Use(writeTimeout(requestTimeout))
 	{
 		noAuth.Handle("/health", healthCheck())
 		noAuth.Handle("/users", s.createUser()).Methods("POST")
 		noAuth.Handle("/users/login", s.loginUser()).Methods("POST")
+		noAuth.Handle("/digest/unsubscribe", s.confirmUnsubscribeDigest()).Methods("GET")
+		noAuth.Handle("/digest/unsubscribe", s.unsubscribeDigest()).Methods("POST")
+	}
+
+	// long-lived streams, registered first and without a write timeout
+	streamRoutes := apiRouter.PathPrefix("").Subrouter()
+	streamRoutes.Use(tokenFromQuery)
+	streamRoutes.Use(s.authenticate(MustAuth))
+	{
+		streamRoutes.Handle("/user/events", s.userEvents()).Methods("GET")
+	}
+
+	optionalAuthStreamRoutes := apiRouter.PathPrefix("").Subrouter()
+	optionalAuthStreamRoutes.Use(tokenFromQuery)
+	optionalAuthStreamRoutes.Use(s.authenticate(!MustAuth))
+	{
+		optionalAuthStreamRoutes.Handle("/articles/{slug}/comments/live", s.liveComments()).Methods("GET")
 	}
 
 	authApiRoutes := apiRouter.PathPrefix("").Subrouter()
+	authApiRoutes.Use(writeTimeout(requestTimeout))
 	authApiRoutes.Use(s.authenticate(MustAuth))
 	{
 		authApiRoutes.Handle("/user", s.getCurrentUser()).Methods("GET")
 		authApiRoutes.Handle("/user", s.updateUser()).Methods("PUT", "PATCH")
+		authApiRoutes.Handle("/user/drafts", s.listDrafts()).Methods("GET")
+		authApiRoutes.Handle("/user/feed-token", s.getFeedToken()).Methods("GET")
+		authApiRoutes.Handle("/user/feed-token", s.rotateFeedToken()).Methods("POST")
+		authApiRoutes.Handle("/user/feed-token", s.revokeFeedToken()).Methods("DELETE")
+		authApiRoutes.Handle("/user/digest", s.getDigestPreference()).Methods("GET")
+		authApiRoutes.Handle("/user/digest", s.updateDigestPreference()).Methods("PUT", "PATCH")
+		authApiRoutes.Handle("/user/bookmarks", s.listBookmarks()).Methods("GET")
+		authApiRoutes.Handle("/user/bookmarks/folders", s.listBookmarkFolders()).Methods("GET")
+		authApiRoutes.Handle("/user/invitations", s.listInvitations()).Methods("GET")
+		authApiRoutes.Handle("/user/notifications", s.listNotifications()).Methods("GET")
+		authApiRoutes.Handle("/user/notifications/read", s.markAllNotificationsRead()).Methods("POST")
+		authApiRoutes.Handle("/user/notifications/{id:[0-9]+}/read", s.markNotificationRead()).Methods("POST")
+		authApiRoutes.Handle("/user/invitations/{slug}", s.declineInvitation()).Methods("DELETE")
+		authApiRoutes.Handle("/user/invitations/{slug}/accept", s.acceptInvitation()).Methods("POST")
+		authApiRoutes.Handle("/user/webhooks", s.createWebhook(conduit.WebhookScopeUser)).Methods("POST")
+		authApiRoutes.Handle("/user/webhooks", s.listWebhooks(conduit.WebhookScopeUser)).Methods("GET")
+		authApiRoutes.Handle("/user/webhooks/{id:[0-9]+}", s.getWebhook(conduit.WebhookScopeUser)).Methods("GET")
+		authApiRoutes.Handle("/user/webhooks/{id:[0-9]+}", s.updateWebhook(conduit.WebhookScopeUser)).Methods("PUT", "PATCH")
+		authApiRoutes.Handle("/user/webhooks/{id:[0-9]+}", s.deleteWebhook(conduit.WebhookScopeUser)).Methods("DELETE")
+		authApiRoutes.Handle("/user/webhooks/{id:[0-9]+}/deliveries", s.listWebhookDeliveries(conduit.WebhookScopeUser)).Methods("GET")
+		authApiRoutes.Handle("/user/webhooks/{id:[0-9]+}/deliveries/{delivery:[0-9]+}/redeliver", s.redeliverWebhook(conduit.WebhookScopeUser)).Methods("POST")
+		authApiRoutes.Handle("/profiles/{username}/follow", s.followUser()).Methods("POST")
+		authApiRoutes.Handle("/profiles/{username}/follow", s.unfollowUser()).Methods("DELETE")
+		authApiRoutes.Handle("/profiles/{username}/block", s.blockUser()).Methods("POST")
+		authApiRoutes.Handle("/profiles/{username}/block", s.unblockUser()).Methods("DELETE")
 		authApiRoutes.Handle("/articles", s.createArticle()).Methods("POST")
 		authApiRoutes.Handle("/articles", s.listArticles()).Methods("GET")
 		authApiRoutes.Handle("/articles/feed", s.articleFeed()).Methods("GET")
+		authApiRoutes.Handle("/articles/search", s.searchArticles()).Methods("GET")
+		authApiRoutes.Handle("/articles/{slug}", s.updateArticle()).Methods("PUT", "PATCH")
+		authApiRoutes.Handle("/articles/{slug}", s.deleteArticle()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/bookmark", s.bookmarkArticle()).Methods("POST")
+		authApiRoutes.Handle("/articles/{slug}/bookmark", s.unbookmarkArticle()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/favorite", s.favoriteArticle()).Methods("POST")
+		authApiRoutes.Handle("/articles/{slug}/favorite", s.unfavoriteArticle()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/coauthors", s.inviteCoAuthor()).Methods("POST")
+		authApiRoutes.Handle("/articles/{slug}/comments", s.createComment()).Methods("POST")
+		authApiRoutes.Handle("/articles/{slug}/comments/{id:[0-9]+}", s.deleteComment()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/comments/{id:[0-9]+}/reactions/{kind}", s.addCommentReaction()).Methods("POST")
+		authApiRoutes.Handle("/articles/{slug}/comments/{id:[0-9]+}/reactions/{kind}", s.removeCommentReaction()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/reactions/{kind}", s.addReaction()).Methods("POST")
+		authApiRoutes.Handle("/articles/{slug}/reactions/{kind}", s.removeReaction()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/coauthors", s.reorderCoAuthors()).Methods("PUT")
+		authApiRoutes.Handle("/articles/{slug}/coauthors/{username}", s.removeCoAuthor()).Methods("DELETE")
+		authApiRoutes.Handle("/articles/{slug}/revisions", s.listRevisions()).Methods("GET")
+		authApiRoutes.Handle("/articles/{slug}/revisions/diff", s.diffRevisions()).Methods("GET")
+		authApiRoutes.Handle("/articles/{slug}/revisions/{revision:[0-9]+}", s.getRevision()).Methods("GET")
+		authApiRoutes.Handle("/articles/{slug}/revisions/{revision:[0-9]+}/restore", s.restoreRevision()).Methods("POST")
+		authApiRoutes.Handle("/tags/suggest", s.suggestTags()).Methods("GET")
+		authApiRoutes.Handle("/tags/{name}/follow", s.followTag()).Methods("POST")
+		authApiRoutes.Handle("/tags/{name}/follow", s.unfollowTag()).Methods("DELETE")
+		authApiRoutes.Handle("/series", s.createSeries()).Methods("POST")
+		authApiRoutes.Handle("/series/{slug}", s.updateSeries()).Methods("PUT", "PATCH")
+		authApiRoutes.Handle("/series/{slug}", s.deleteSeries()).Methods("DELETE")
+		authApiRoutes.Handle("/series/{slug}/articles", s.setSeriesArticles()).Methods("PUT")
+	}
+
+	optionalAuthApiRoutes := apiRouter.PathPrefix("").Subrouter()
+	optionalAuthApiRoutes.Use(writeTimeout(requestTimeout))
+	optionalAuthApiRoutes.Use(s.authenticate(!MustAuth))
+	{
+		optionalAuthApiRoutes.Handle("/articles/{slug}", s.getArticle()).Methods("GET")
+		optionalAuthApiRoutes.Handle("/articles/{slug}/comments", s.listComments()).Methods("GET")
+		optionalAuthApiRoutes.Handle("/articles/{slug}/comments/{id:[0-9]+}/reactions", s.listCommentReactions()).Methods("GET")
+		optionalAuthApiRoutes.Handle("/articles/{slug}/reactions", s.listReactions()).Methods("GET")
+		optionalAuthApiRoutes.Handle("/series", s.listSeries()).Methods("GET")
+		optionalAuthApiRoutes.Handle("/series/{slug}", s.getSeries()).Methods("GET")
+	}
+
+	moderatorRoutes := authApiRoutes.PathPrefix("").Subrouter()
+	moderatorRoutes.Use(s.requireModerator)
+	{
+		moderatorRoutes.Handle("/tags/audit", s.tagAuditLog()).Methods("GET")
+		moderatorRoutes.Handle("/tags/prune", s.deleteUnusedTags()).Methods("POST")
+		moderatorRoutes.Handle("/tags/aliases/{alias}", s.removeTagAlias()).Methods("DELETE")
+		moderatorRoutes.Handle("/tags/{name}", s.renameTag()).Methods("PUT", "PATCH")
+		moderatorRoutes.Handle("/tags/{name}", s.deleteTag()).Methods("DELETE")
+		moderatorRoutes.Handle("/tags/{name}/merge", s.mergeTags()).Methods("POST")
+		moderatorRoutes.Handle("/tags/{name}/aliases", s.addTagAlias()).Methods("POST")
+		moderatorRoutes.Handle("/webhooks", s.createWebhook(conduit.WebhookScopeSite)).Methods("POST")
+		moderatorRoutes.Handle("/webhooks", s.listWebhooks(conduit.WebhookScopeSite)).Methods("GET")
+		moderatorRoutes.Handle("/webhooks/{id:[0-9]+}", s.getWebhook(conduit.WebhookScopeSite)).Methods("GET")
+		moderatorRoutes.Handle("/webhooks/{id:[0-9]+}", s.updateWebhook(conduit.WebhookScopeSite)).Methods("PUT", "PATCH")
+		moderatorRoutes.Handle("/webhooks/{id:[0-9]+}", s.deleteWebhook(conduit.WebhookScopeSite)).Methods("DELETE")
+		moderatorRoutes.Handle("/webhooks/{id:[0-9]+}/deliveries", s.listWebhookDeliveries(conduit.WebhookScopeSite)).Methods("GET")
+		moderatorRoutes.Handle("/webhooks/{id:[0-9]+}/deliveries/{delivery:[0-9]+}/redeliver", s.redeliverWebhook(conduit.WebhookScopeSite)).Methods("POST")
 	}
 }
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const schedulerInterval = time.Minute
+
+// runScheduler publishes scheduled articles once their publish time has
+// passed. It runs until ctx is cancelled.
+func (s *Server) runScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.publishScheduledArticles(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) publishScheduledArticles(ctx context.Context) {
+	articles, err := s.articleService.PublishScheduledArticles(ctx, time.Now())
+	if err != nil {
+		log.Printf("cannot publish scheduled articles: %v", err)
+		return
+	}
+
+	for _, a := range articles {
+		log.Printf("published scheduled article %q", a.Slug)
+		s.indexArticle(ctx, a)
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+func (s *Server) createSeries() http.HandlerFunc {
+	type Input struct {
+		Series struct {
+			Title       string `json:"title" validate:"required"`
+			Description string `json:"description"`
+		} `json:"series"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input.Series); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		user := userFromContext(r.Context())
+		series := conduit.Series{
+			Title:       input.Series.Title,
+			Description: input.Series.Description,
+			Owner:       user,
+		}
+
+		if err := s.seriesService.CreateSeries(r.Context(), &series); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		series.SetOwnerProfile(user)
+
+		writeJSON(w, http.StatusCreated, M{"series": series})
+	}
+}
+
+func (s *Server) listSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := conduit.SeriesFilter{}
+
+		if v := query.Get("owner"); v != "" {
+			filter.OwnerUsername = &v
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		series, err := s.seriesService.Series(r.Context(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		user := userFromContext(r.Context())
+		for _, ss := range series {
+			presentSeries(ss, user)
+		}
+
+		writeJSON(w, http.StatusOK, M{"series": series})
+	}
+}
+
+func (s *Server) getSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		series, ok := s.seriesFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		presentSeries(series, userFromContext(r.Context()))
+
+		writeJSON(w, http.StatusOK, M{"series": series})
+	}
+}
+
+func (s *Server) updateSeries() http.HandlerFunc {
+	type Input struct {
+		Series struct {
+			Title       *string `json:"title,omitempty" validate:"omitempty,min=1"`
+			Description *string `json:"description,omitempty"`
+		} `json:"series"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input.Series); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		series, ok := s.ownedSeries(w, r)
+		if !ok {
+			return
+		}
+
+		patch := conduit.SeriesPatch{
+			Title:       input.Series.Title,
+			Description: input.Series.Description,
+		}
+
+		if err := s.seriesService.UpdateSeries(r.Context(), series, patch); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		presentSeries(series, userFromContext(r.Context()))
+
+		writeJSON(w, http.StatusOK, M{"series": series})
+	}
+}
+
+func (s *Server) deleteSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		series, ok := s.ownedSeries(w, r)
+		if !ok {
+			return
+		}
+
+		if err := s.seriesService.DeleteSeries(r.Context(), series); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+// setSeriesArticles replaces the articles of a series with the given slugs in
+// order, which both adds, removes and reorders articles in one call.
+func (s *Server) setSeriesArticles() http.HandlerFunc {
+	type Input struct {
+		Articles []string `json:"articles" validate:"required"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		series, ok := s.ownedSeries(w, r)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		user := userFromContext(ctx)
+		ids := make([]uint, 0, len(input.Articles))
+		seen := make(map[uint]bool, len(input.Articles))
+
+		for _, slug := range input.Articles {
+			article, err := s.articleService.ArticleBySlug(ctx, slug)
+			if err != nil {
+				switch {
+				case errors.Is(err, conduit.ErrNotFound):
+					errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"articles": []string{slug + " does not exist"}})
+				default:
+					serverError(w, err)
+				}
+				return
+			}
+
+			if !article.CanEdit(user) {
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"articles": []string{slug + " is not yours to add"}})
+				return
+			}
+
+			if seen[article.ID] {
+				errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"articles": []string{slug + " is listed twice"}})
+				return
+			}
+
+			seen[article.ID] = true
+			ids = append(ids, article.ID)
+		}
+
+		if err := s.seriesService.SetSeriesArticles(ctx, series, ids); err != nil {
+			switch {
+			case errors.Is(err, conduit.ErrArticleInSeries):
+				errorResponse(w, http.StatusConflict, ErrorM{"articles": []string{"an article can only be part of one series"}})
+			default:
+				serverError(w, err)
+			}
+			return
+		}
+
+		presentSeries(series, user)
+
+		writeJSON(w, http.StatusOK, M{"series": series})
+	}
+}
+
+func (s *Server) seriesFromRequest(w http.ResponseWriter, r *http.Request) (*conduit.Series, bool) {
+	series, err := s.seriesService.SeriesBySlug(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		switch {
+		case errors.Is(err, conduit.ErrNotFound):
+			notFoundError(w)
+		default:
+			serverError(w, err)
+		}
+		return nil, false
+	}
+
+	return series, true
+}
+
+func (s *Server) ownedSeries(w http.ResponseWriter, r *http.Request) (*conduit.Series, bool) {
+	series, ok := s.seriesFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if series.OwnerID != userFromContext(r.Context()).ID {
+		forbiddenError(w)
+		return nil, false
+	}
+
+	return series, true
+}
+
+// presentSeries hides unpublished articles from everyone but the owner.
+func presentSeries(series *conduit.Series, user *conduit.User) {
+	if series.OwnerID != user.ID {
+		series.Articles = series.PublishedArticles()
+	}
+
+	series.SetOwnerProfile(user)
+}
@@ -1,38 +1,139 @@
 package server
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/digest"
+	"github.com/msksgm/go-realworld-msksgm-copy/markdown"
+	"github.com/msksgm/go-realworld-msksgm-copy/outbox"
 	"github.com/msksgm/go-realworld-msksgm-copy/postgres"
+	"github.com/msksgm/go-realworld-msksgm-copy/webhook"
 )
 
+// relayInterval is how often domain events are relayed from the outbox.
+const relayInterval = time.Second
+
 type Server struct {
-	server         *http.Server
-	router         *mux.Router
-	userService    conduit.UserService
-	articleService conduit.ArticleService
+	server              *http.Server
+	router              *mux.Router
+	userService         conduit.UserService
+	articleService      conduit.ArticleService
+	tagService          conduit.TagService
+	seriesService       conduit.SeriesService
+	coAuthorService     conduit.CoAuthorService
+	bookmarkService     conduit.BookmarkService
+	reactionService     conduit.ReactionService
+	commentService      conduit.CommentService
+	notificationService conduit.NotificationService
+	eventService        conduit.EventService
+	webhookService      conduit.WebhookService
+	dispatcher          *webhook.Dispatcher
+	relay               *outbox.Relay
+	digestService       conduit.DigestService
+	feedTokenService    conduit.FeedTokenService
+	sitemapService      conduit.SitemapService
+	digestSender        *digest.Sender
+	hub                 *hub
+
+	// shutdown is closed when the server starts shutting down, to close the
+	// WebSockets counted in live and stop the background workers. liveMu
+	// orders closing it against counting new WebSockets, see trackLive.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	liveMu       sync.Mutex
+	live         sync.WaitGroup
+	workers      sync.WaitGroup
+	searchIndex  conduit.SearchIndex
+	renderer     conduit.Renderer
+
+	// baseURL is where the site is served, without a trailing slash. When
+	// empty, links are made from the request's host.
+	baseURL string
+	robots  string
+}
+
+// Options tunes the server. The zero value uses the defaults.
+type Options struct {
+	// CommentMaxDepth limits how deeply comment replies may nest.
+	CommentMaxDepth int
+
+	// EventPublisher receives the domain events from the outbox. When nil
+	// they are left in the outbox.
+	EventPublisher conduit.EventPublisher
+
+	// Mailer sends the article digests. When nil no digest is sent.
+	Mailer conduit.Mailer
+
+	// BaseURL is the canonical address of the site, for the links in
+	// emails, feeds and the sitemap.
+	BaseURL string
+
+	// RobotsTxt is served as /robots.txt. When empty a default pointing at
+	// the sitemap is served.
+	RobotsTxt string
 }
 
-func NewServer(db *postgres.DB) *Server {
+// NewServer wires the postgres services together. When searchIndex is nil
+// article search is answered by postgres full-text search.
+func NewServer(db *postgres.DB, searchIndex conduit.SearchIndex, opts Options) *Server {
 	s := Server{
+		// no WriteTimeout: it would cut off event streams, so ordinary
+		// routes are bounded by writeTimeout instead
 		server: &http.Server{
-			WriteTimeout: 5 * time.Second,
-			ReadTimeout:  5 * time.Second,
-			IdleTimeout:  5 * time.Second,
+			ReadTimeout: 5 * time.Second,
+			IdleTimeout: 5 * time.Second,
 		},
-		router: mux.NewRouter().StrictSlash(true),
+		router:   mux.NewRouter().StrictSlash(true),
+		shutdown: make(chan struct{}),
+		baseURL:  strings.TrimSuffix(opts.BaseURL, "/"),
+		robots:   opts.RobotsTxt,
 	}
 
+	s.server.RegisterOnShutdown(s.beginShutdown)
+
 	s.routes()
 
 	as := postgres.NewArticleService(db)
 	s.userService = postgres.NewUserService(db)
 	s.articleService = as
+	s.tagService = postgres.NewTagService(db)
+	s.seriesService = postgres.NewSeriesService(db)
+	s.coAuthorService = postgres.NewCoAuthorService(db)
+	s.bookmarkService = postgres.NewBookmarkService(db)
+	s.reactionService = postgres.NewReactionService(db)
+	s.commentService = postgres.NewCommentService(db, opts.CommentMaxDepth)
+	s.notificationService = postgres.NewNotificationService(db)
+	s.eventService = postgres.NewEventService(db)
+	s.webhookService = postgres.NewWebhookService(db)
+	s.dispatcher = webhook.NewDispatcher(s.webhookService)
+
+	s.digestService = postgres.NewDigestService(db)
+	s.feedTokenService = postgres.NewFeedTokenService(db)
+	s.sitemapService = postgres.NewSitemapService(db)
+
+	if opts.Mailer != nil {
+		s.digestSender = digest.NewSender(as, s.digestService, opts.Mailer, opts.BaseURL, hmacSampleSecret)
+	}
+
+	if opts.EventPublisher != nil {
+		s.relay = outbox.NewRelay(postgres.NewOutboxService(db), opts.EventPublisher)
+	}
+
+	s.hub = newHub()
+	s.searchIndex = searchIndex
+	s.renderer = markdown.NewRenderer()
+
+	if s.searchIndex == nil {
+		s.searchIndex = postgres.NewSearchIndex(db)
+	}
+
 	s.server.Handler = s.router
 
 	return &s
@@ -43,10 +144,91 @@ func (s *Server) Run(port string) error {
 		port = ":" + port
 	}
 	s.server.Addr = port
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-s.shutdown
+		cancel()
+	}()
+
+	s.startWorker(func() { s.runScheduler(ctx, schedulerInterval) })
+	s.startWorker(func() { s.relayEvents(ctx) })
+	s.startWorker(func() { s.dispatcher.Run(ctx, webhookInterval) })
+
+	if s.relay != nil {
+		s.startWorker(func() { s.relay.Run(ctx, relayInterval) })
+	}
+
+	if s.digestSender != nil {
+		s.startWorker(func() { s.digestSender.Run(ctx, digestInterval) })
+	}
+
 	log.Printf("server starting on %s", port)
 	return s.server.ListenAndServe()
 }
 
+// startWorker runs fn in the background, counted in workers.
+func (s *Server) startWorker(fn func()) {
+	s.workers.Add(1)
+
+	go func() {
+		defer s.workers.Done()
+		fn()
+	}()
+}
+
+// Shutdown stops accepting requests and waits for the open ones, the live
+// connections and the background workers to finish, or for ctx to be done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	// the server runs its shutdown hooks without waiting for them, and no
+	// WebSocket may be counted once live is waited on
+	s.beginShutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.live.Wait()
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) beginShutdown() {
+	s.shutdownOnce.Do(func() {
+		s.liveMu.Lock()
+		defer s.liveMu.Unlock()
+
+		close(s.shutdown)
+	})
+}
+
+// trackLive counts a WebSocket in live, for Shutdown to wait for it. It must
+// be called before the connection is hijacked, as Shutdown no longer sees it
+// afterwards, and fails once shutdown has begun.
+func (s *Server) trackLive() bool {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+
+	select {
+	case <-s.shutdown:
+		return false
+	default:
+	}
+
+	s.live.Add(1)
+	return true
+}
+
 func healthCheck() http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		resp := M{
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/sitemap"
+)
+
+// sitemapIndex serves the sitemap, or an index of its pages once the site
+// has more pages than one sitemap may list.
+func (s *Server) sitemapIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		count, err := s.sitemapService.SitemapEntryCount(r.Context())
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		if count <= sitemap.MaxURLs {
+			s.writeSitemapPage(w, r, 1)
+			return
+		}
+
+		pages := make([]sitemap.URL, (count+sitemap.MaxURLs-1)/sitemap.MaxURLs)
+		for i := range pages {
+			pages[i].Loc = s.absoluteURL(r, fmt.Sprintf("/sitemaps/%d.xml", i+1))
+		}
+
+		var buf bytes.Buffer
+		if err := sitemap.WriteIndex(&buf, pages); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeXML(w, buf.Bytes())
+	}
+}
+
+func (s *Server) sitemapPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(mux.Vars(r)["page"])
+		if err != nil || page < 1 {
+			notFoundError(w)
+			return
+		}
+
+		s.writeSitemapPage(w, r, page)
+	}
+}
+
+func (s *Server) writeSitemapPage(w http.ResponseWriter, r *http.Request, page int) {
+	entries, err := s.sitemapService.SitemapEntries(r.Context(), sitemap.MaxURLs, (page-1)*sitemap.MaxURLs)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+
+	if len(entries) == 0 && page > 1 {
+		notFoundError(w)
+		return
+	}
+
+	urls := make([]sitemap.URL, len(entries))
+	for i, e := range entries {
+		path := "/articles/"
+		if e.Kind == conduit.SitemapProfile {
+			path = "/profiles/"
+		}
+
+		urls[i] = sitemap.URL{Loc: s.absoluteURL(r, path+url.PathEscape(e.Key)), LastMod: e.UpdatedAt}
+	}
+
+	var buf bytes.Buffer
+	if err := sitemap.WriteURLSet(&buf, urls); err != nil {
+		serverError(w, err)
+		return
+	}
+
+	writeXML(w, buf.Bytes())
+}
+
+// robotsTxt serves the configured robots.txt, or one that keeps crawlers
+// out of the API and private feeds and points them at the sitemap.
+func (s *Server) robotsTxt() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := s.robots
+		if body == "" {
+			body = "User-agent: *\nDisallow: /api/\nDisallow: /feeds/me/\n\nSitemap: " + s.absoluteURL(r, "/sitemap.xml") + "\n"
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}
+}
+
+func writeXML(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
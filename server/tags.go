@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+const defaultSuggestLimit = 10
+
+func (s *Server) suggestTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		prefix := conduit.NormalizeTagName(query.Get("prefix"))
+
+		if prefix == "" {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"prefix": []string{"this field is required"}})
+			return
+		}
+
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit <= 0 {
+			limit = defaultSuggestLimit
+		}
+
+		tags, err := s.tagService.SuggestTags(r.Context(), prefix, limit)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		names := make([]string, len(tags))
+		for i, t := range tags {
+			names[i] = t.Name
+		}
+
+		writeJSON(w, http.StatusOK, M{"tags": names})
+	}
+}
+
+// similarTagWarnings reports tags that do not exist yet but are close to an
+// existing tag, which usually means the author is creating a near duplicate.
+func (s *Server) similarTagWarnings(ctx context.Context, tags []*conduit.Tag) ([]M, error) {
+	warnings := make([]M, 0)
+
+	for _, t := range tags {
+		similar, err := s.tagService.SimilarTags(ctx, t.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(similar) == 0 {
+			continue
+		}
+
+		names := make([]string, len(similar))
+		for i, st := range similar {
+			names[i] = st.Name
+		}
+
+		warnings = append(warnings, M{"tag": t.Name, "similarTags": names})
+	}
+
+	return warnings, nil
+}
+
+func withWarnings(resp M, warnings []M) M {
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	return resp
+}
+
+func (s *Server) followTag() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tag, err := s.tagService.FollowTag(ctx, userFromContext(ctx), mux.Vars(r)["name"])
+		if err != nil {
+			tagError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"tag": M{"name": tag.Name, "following": true}})
+	}
+}
+
+func (s *Server) unfollowTag() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		tag, err := s.tagService.UnfollowTag(ctx, userFromContext(ctx), mux.Vars(r)["name"])
+		if err != nil {
+			tagError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"tag": M{"name": tag.Name, "following": false}})
+	}
+}
+
+func (s *Server) renameTag() http.HandlerFunc {
+	type Input struct {
+		Tag struct {
+			Name string `json:"name" validate:"required"`
+		} `json:"tag"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		input.Tag.Name = conduit.NormalizeTagName(input.Tag.Name)
+		if err := validate.Struct(input.Tag); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		ctx := r.Context()
+		tag, err := s.tagService.RenameTag(ctx, userFromContext(ctx), mux.Vars(r)["name"], input.Tag.Name)
+		if err != nil {
+			tagError(w, err)
+			return
+		}
+
+		s.reindexArticles(ctx, conduit.ArticleFilter{Tag: &tag.Name})
+
+		writeJSON(w, http.StatusOK, M{"tag": tag.Name})
+	}
+}
+
+func (s *Server) mergeTags() http.HandlerFunc {
+	type Input struct {
+		Into string `json:"into" validate:"required"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		input.Into = conduit.NormalizeTagName(input.Into)
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		ctx := r.Context()
+		tag, err := s.tagService.MergeTags(ctx, userFromContext(ctx), mux.Vars(r)["name"], input.Into)
+		if err != nil {
+			tagError(w, err)
+			return
+		}
+
+		s.reindexArticles(ctx, conduit.ArticleFilter{Tag: &tag.Name})
+
+		writeJSON(w, http.StatusOK, M{"tag": tag.Name})
+	}
+}
+
+func (s *Server) addTagAlias() http.HandlerFunc {
+	type Input struct {
+		Alias string `json:"alias" validate:"required"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		input.Alias = conduit.NormalizeTagName(input.Alias)
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		ctx := r.Context()
+		name := mux.Vars(r)["name"]
+		if err := s.tagService.AddTagAlias(ctx, userFromContext(ctx), name, input.Alias); err != nil {
+			tagError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, M{"tag": name, "alias": input.Alias})
+	}
+}
+
+func (s *Server) removeTagAlias() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := s.tagService.RemoveTagAlias(ctx, userFromContext(ctx), mux.Vars(r)["alias"]); err != nil {
+			tagError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+func (s *Server) deleteTag() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if err := s.tagService.DeleteTag(ctx, userFromContext(ctx), mux.Vars(r)["name"]); err != nil {
+			tagError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+func (s *Server) deleteUnusedTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		n, err := s.tagService.DeleteUnusedTags(ctx, userFromContext(ctx))
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"deleted": n})
+	}
+}
+
+func (s *Server) tagAuditLog() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := conduit.TagAuditFilter{}
+
+		if v := query.Get("tag"); v != "" {
+			filter.TagName = &v
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		entries, err := s.tagService.TagAuditLog(r.Context(), filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"entries": entries})
+	}
+}
+
+func tagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, conduit.ErrNotFound):
+		notFoundError(w)
+	case errors.Is(err, conduit.ErrDuplicateTag):
+		errorResponse(w, http.StatusConflict, ErrorM{"tag": []string{"a tag or alias with this name already exists"}})
+	case errors.Is(err, conduit.ErrTagInUse):
+		errorResponse(w, http.StatusConflict, ErrorM{"tag": []string{"this tag is still used by articles"}})
+	default:
+		serverError(w, err)
+	}
+}
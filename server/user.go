@@ -159,6 +159,10 @@ func (s *Server) updateUser() http.HandlerFunc {
 			return
 		}
 
+		if patch.Username != nil {
+			s.reindexArticles(ctx, conduit.ArticleFilter{AuthorUsername: &user.Username})
+		}
+
 		user.Token = userTokenFromContext(ctx)
 
 		writeJSON(w, http.StatusOK, M{"user": user})
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"log"
@@ -33,6 +35,17 @@ func readJSON(body io.Reader, input interface{}) error {
 	return json.NewDecoder(body).Decode(input)
 }
 
+// randomToken returns 32 random bytes, hex encoded, for secrets handed out to
+// users.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
 var hmacSampleSecret = []byte("sample-secret")
 
 func generateUserToken(user *conduit.User) (string, error) {
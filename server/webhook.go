@@ -0,0 +1,277 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+	"github.com/msksgm/go-realworld-msksgm-copy/webhook"
+)
+
+// webhookInterval is how often queued webhook deliveries are sent.
+const webhookInterval = 15 * time.Second
+
+// The webhook handlers serve both scopes: users manage their own webhooks
+// under /user/webhooks and moderators the site ones under /webhooks.
+
+func (s *Server) createWebhook(scope string) http.HandlerFunc {
+	type Input struct {
+		URL    string   `json:"url" validate:"required,url"`
+		Events []string `json:"events" validate:"required,min=1,dive,oneof=article.published article.updated comment.created user.followed"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		if !isWebhookURL(input.URL) {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"url": []string{"url must use http or https and point to a public host"}})
+			return
+		}
+
+		secret, err := randomToken()
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		ctx := r.Context()
+		webhook := conduit.Webhook{
+			OwnerID: userFromContext(ctx).ID,
+			Scope:   scope,
+			URL:     input.URL,
+			Secret:  secret,
+			Events:  input.Events,
+			Active:  true,
+		}
+
+		if err := s.webhookService.CreateWebhook(ctx, &webhook); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, M{"webhook": webhook})
+	}
+}
+
+func (s *Server) listWebhooks(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		query := r.URL.Query()
+		filter := conduit.WebhookFilter{Scope: &scope}
+
+		if scope == conduit.WebhookScopeUser {
+			filter.OwnerID = &userFromContext(ctx).ID
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		webhooks, err := s.webhookService.Webhooks(ctx, filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		for _, webhook := range webhooks {
+			webhook.Secret = ""
+		}
+
+		writeJSON(w, http.StatusOK, M{"webhooks": webhooks})
+	}
+}
+
+func (s *Server) getWebhook(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhook, ok := s.webhookFromRequest(w, r, scope)
+		if !ok {
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"webhook": webhook})
+	}
+}
+
+func (s *Server) updateWebhook(scope string) http.HandlerFunc {
+	type Input struct {
+		URL    *string  `json:"url,omitempty" validate:"omitempty,url"`
+		Events []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=article.published article.updated comment.created user.followed"`
+		Active *bool    `json:"active,omitempty"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		input := Input{}
+
+		if err := readJSON(r.Body, &input); err != nil {
+			badRequestError(w)
+			return
+		}
+
+		if err := validate.Struct(input); err != nil {
+			validationError(w, err)
+			return
+		}
+
+		if input.URL != nil && !isWebhookURL(*input.URL) {
+			errorResponse(w, http.StatusUnprocessableEntity, ErrorM{"url": []string{"url must use http or https and point to a public host"}})
+			return
+		}
+
+		webhook, ok := s.webhookFromRequest(w, r, scope)
+		if !ok {
+			return
+		}
+
+		patch := conduit.WebhookPatch{
+			URL:    input.URL,
+			Events: input.Events,
+			Active: input.Active,
+		}
+
+		if err := s.webhookService.UpdateWebhook(r.Context(), webhook, patch); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"webhook": webhook})
+	}
+}
+
+func (s *Server) deleteWebhook(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhook, ok := s.webhookFromRequest(w, r, scope)
+		if !ok {
+			return
+		}
+
+		if err := s.webhookService.DeleteWebhook(r.Context(), webhook); err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{})
+	}
+}
+
+// listWebhookDeliveries returns the webhook's delivery log, newest first.
+// ?status= keeps one of pending, delivered or dead.
+func (s *Server) listWebhookDeliveries(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhook, ok := s.webhookFromRequest(w, r, scope)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+		filter := conduit.WebhookDeliveryFilter{}
+
+		if v := query.Get("status"); v != "" {
+			filter.Status = &v
+		}
+
+		filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+		filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+		deliveries, err := s.webhookService.WebhookDeliveries(r.Context(), webhook, filter)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, M{"deliveries": deliveries})
+	}
+}
+
+// redeliverWebhook queues the payload of a past delivery again, whatever
+// became of it.
+func (s *Server) redeliverWebhook(scope string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhook, ok := s.webhookFromRequest(w, r, scope)
+		if !ok {
+			return
+		}
+
+		ctx := r.Context()
+		id, _ := strconv.ParseUint(mux.Vars(r)["delivery"], 10, 64)
+
+		deliveries, err := s.webhookService.WebhookDeliveries(ctx, webhook, conduit.WebhookDeliveryFilter{ID: &id})
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		if len(deliveries) == 0 {
+			notFoundError(w)
+			return
+		}
+
+		delivery, err := s.webhookService.Redeliver(ctx, deliveries[0])
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, M{"delivery": delivery})
+	}
+}
+
+// webhookFromRequest loads the {id} webhook of scope. A user webhook is only
+// found by its owner, and its secret is never sent back.
+func (s *Server) webhookFromRequest(w http.ResponseWriter, r *http.Request, scope string) (*conduit.Webhook, bool) {
+	ctx := r.Context()
+	id64, _ := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	id := uint(id64)
+	filter := conduit.WebhookFilter{ID: &id, Scope: &scope}
+
+	if scope == conduit.WebhookScopeUser {
+		filter.OwnerID = &userFromContext(ctx).ID
+	}
+
+	webhooks, err := s.webhookService.Webhooks(ctx, filter)
+	if err != nil {
+		serverError(w, err)
+		return nil, false
+	}
+
+	if len(webhooks) == 0 {
+		notFoundError(w)
+		return nil, false
+	}
+
+	webhook := webhooks[0]
+	webhook.Secret = ""
+
+	return webhook, true
+}
+
+// isWebhookURL checks the url is http(s) and, when the host is an IP
+// address, that it is a public one. Host names are checked again by the
+// dispatcher each time it connects.
+func isWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+
+	if strings.EqualFold(u.Hostname(), "localhost") {
+		return false
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !webhook.IsPublicIP(ip) {
+		return false
+	}
+
+	return true
+}
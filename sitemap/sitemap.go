@@ -0,0 +1,73 @@
+// Package sitemap writes sitemaps and sitemap indexes as described on
+// sitemaps.org.
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// MaxURLs is how many URLs one sitemap may list. Larger sites are split into
+// several sitemaps listed by an index.
+const MaxURLs = 50000
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []entry  `xml:"url"`
+}
+
+type index struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Sitemaps []entry  `xml:"sitemap"`
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func newEntry(u URL) entry {
+	e := entry{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		e.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+	}
+	return e
+}
+
+// WriteURLSet writes a sitemap of urls, which must not be more than MaxURLs.
+func WriteURLSet(w io.Writer, urls []URL) error {
+	set := urlSet{Xmlns: xmlns, URLs: make([]entry, len(urls))}
+	for i, u := range urls {
+		set.URLs[i] = newEntry(u)
+	}
+
+	return write(w, set)
+}
+
+// WriteIndex writes a sitemap index listing sitemaps.
+func WriteIndex(w io.Writer, sitemaps []URL) error {
+	idx := index{Xmlns: xmlns, Sitemaps: make([]entry, len(sitemaps))}
+	for i, u := range sitemaps {
+		idx.Sitemaps[i] = newEntry(u)
+	}
+
+	return write(w, idx)
+}
+
+func write(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a receiver resolves to an address
+// webhooks may not reach, such as loopback or a private network.
+var ErrForbiddenAddress = errors.New("address not allowed for webhooks")
+
+// NewClient returns an HTTP client that only connects to public addresses.
+// The check runs on the resolved address when dialing, so a receiver cannot
+// get around it by rebinding its DNS name after the webhook was saved, nor
+// by redirecting.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialControl,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// deniedNets are the ranges webhooks may not reach: anything local, private,
+// shared, reserved or translated to one of those. IPv4 addresses mapped into
+// IPv6 are matched against the IPv4 ranges.
+var deniedNets = parseCIDRs(
+	"0.0.0.0/8",       // this network
+	"10.0.0.0/8",      // private
+	"100.64.0.0/10",   // carrier-grade NAT
+	"127.0.0.0/8",     // loopback
+	"169.254.0.0/16",  // link-local, cloud metadata services
+	"172.16.0.0/12",   // private
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"192.168.0.0/16",  // private
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"224.0.0.0/4",     // multicast
+	"240.0.0.0/4",     // reserved, broadcast
+	"::/128",          // unspecified
+	"::1/128",         // loopback
+	"64:ff9b::/96",    // NAT64
+	"64:ff9b:1::/48",  // local NAT64
+	"100::/64",        // discard
+	"2001:db8::/32",   // documentation
+	"2002::/16",       // 6to4, may embed any IPv4 address
+	"fc00::/7",        // unique local
+	"fe80::/10",       // link-local
+	"ff00::/8",        // multicast
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// IsPublicIP reports whether ip is an address webhooks may be sent to.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range deniedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
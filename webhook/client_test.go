@@ -0,0 +1,37 @@
+package webhook
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":     true,
+		"2606:2800:220:1::": true,
+		"127.0.0.1":         false,
+		"10.1.2.3":          false,
+		"172.31.0.1":        false,
+		"192.168.1.1":       false,
+		"169.254.169.254":   false,
+		"100.64.0.1":        false,
+		"0.0.0.0":           false,
+		"0.1.2.3":           false,
+		"198.18.0.1":        false,
+		"224.0.0.1":         false,
+		"255.255.255.255":   false,
+		"::":                false,
+		"::1":               false,
+		"::ffff:127.0.0.1":  false,
+		"::ffff:10.0.0.1":   false,
+		"64:ff9b::7f00:1":   false,
+		"2002:7f00:1::":     false,
+		"fd00::1":           false,
+		"fe80::1":           false,
+		"ff02::1":           false,
+	} {
+		if got := IsPublicIP(net.ParseIP(addr)); got != public {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", addr, got, public)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package webhook sends queued webhook deliveries to their receivers.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+// Headers set on every delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret, prefixed with
+// "sha256="; receivers should reject timestamps too far from their clock.
+const (
+	EventHeader     = "X-Conduit-Event"
+	DeliveryHeader  = "X-Conduit-Delivery"
+	TimestampHeader = "X-Conduit-Timestamp"
+	SignatureHeader = "X-Conduit-Signature"
+)
+
+// Sign returns the signature header value of body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body sent at
+// timestamp.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Dispatcher claims due deliveries and sends them. Failed attempts are
+// retried with exponential backoff until MaxAttempts, after which the
+// delivery is dead.
+type Dispatcher struct {
+	Service conduit.WebhookService
+	Client  *http.Client
+
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// Lease is how long a claimed delivery is held back from other
+	// dispatchers while it is being sent. It is raised to BatchSize times
+	// Client.Timeout when shorter, so a batch of slow receivers is not
+	// claimed again before it has been sent.
+	Lease     time.Duration
+	BatchSize int
+
+	// Now is the clock deliveries are claimed, retried and signed by.
+	Now func() time.Time
+}
+
+func NewDispatcher(service conduit.WebhookService) *Dispatcher {
+	return &Dispatcher{
+		Service:     service,
+		Client:      NewClient(10 * time.Second),
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+		Lease:       5 * time.Minute,
+		BatchSize:   20,
+		Now:         time.Now,
+	}
+}
+
+// Run delivers due deliveries every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil {
+			log.Printf("cannot deliver webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends one batch of due deliveries and returns how many were
+// attempted.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := d.Service.ClaimDueDeliveries(ctx, d.Now(), d.lease(), d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		attempt := d.send(ctx, delivery)
+
+		var retryAt *time.Time
+		if !attempt.Succeeded() && delivery.Attempts+1 < d.MaxAttempts {
+			at := d.Now().Add(d.backoff(delivery.Attempts + 1))
+			retryAt = &at
+		}
+
+		if err := d.Service.RecordDeliveryAttempt(ctx, delivery, attempt, retryAt); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) lease() time.Duration {
+	if batch := time.Duration(d.BatchSize) * d.Client.Timeout; d.Lease < batch {
+		return batch
+	}
+
+	return d.Lease
+}
+
+// backoff is how long to wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+
+	return wait
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *conduit.WebhookDelivery) conduit.DeliveryAttempt {
+	timestamp := d.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return conduit.DeliveryAttempt{Err: err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Conduit-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return conduit.DeliveryAttempt{Err: err}
+	}
+
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt := conduit.DeliveryAttempt{StatusCode: resp.StatusCode}
+	if !attempt.Succeeded() {
+		attempt.Err = fmt.Errorf("receiver answered %s", resp.Status)
+	}
+
+	return attempt
+}
@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/msksgm/go-realworld-msksgm-copy/conduit"
+)
+
+type recordedAttempt struct {
+	delivery *conduit.WebhookDelivery
+	attempt  conduit.DeliveryAttempt
+	retryAt  *time.Time
+}
+
+// fakeWebhookService hands out the queued deliveries once and records the
+// attempts. The other WebhookService methods are not used by the dispatcher.
+type fakeWebhookService struct {
+	conduit.WebhookService
+
+	queue    []*conduit.WebhookDelivery
+	lease    time.Duration
+	recorded []recordedAttempt
+}
+
+func (fs *fakeWebhookService) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*conduit.WebhookDelivery, error) {
+	fs.lease = lease
+
+	claimed := fs.queue
+	if len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	fs.queue = fs.queue[len(claimed):]
+
+	return claimed, nil
+}
+
+func (fs *fakeWebhookService) RecordDeliveryAttempt(ctx context.Context, delivery *conduit.WebhookDelivery, attempt conduit.DeliveryAttempt, retryAt *time.Time) error {
+	fs.recorded = append(fs.recorded, recordedAttempt{delivery, attempt, retryAt})
+	return nil
+}
+
+// sentAt is the dispatcher's clock in these tests, as a Unix time so it can
+// be compared with the timestamp header directly.
+const sentAt = 1622548800
+
+// receiver is a webhook endpoint answering every delivery with status and
+// keeping the last request it got.
+type receiver struct {
+	*httptest.Server
+
+	status int
+	got    *http.Request
+	body   []byte
+}
+
+func startReceiver(t *testing.T, status int) *receiver {
+	t.Helper()
+
+	rc := &receiver{status: status}
+
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc.got = r
+		rc.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(rc.status)
+	}))
+	t.Cleanup(rc.Close)
+
+	return rc
+}
+
+// dispatch runs one DeliverDue of the deliveries against the receiver and
+// returns what the service recorded.
+func (rc *receiver) dispatch(t *testing.T, configure func(*Dispatcher), deliveries ...*conduit.WebhookDelivery) *fakeWebhookService {
+	t.Helper()
+
+	for _, delivery := range deliveries {
+		delivery.Webhook = &conduit.Webhook{ID: 1, URL: rc.URL, Secret: "s3cret", Active: true}
+	}
+
+	service := &fakeWebhookService{queue: deliveries}
+
+	d := NewDispatcher(service)
+	// the receiver listens on loopback, which NewClient refuses
+	d.Client = rc.Client()
+	d.Now = func() time.Time { return time.Unix(sentAt, 0) }
+	if configure != nil {
+		configure(d)
+	}
+
+	n, err := d.DeliverDue(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverDue: %v", err)
+	}
+
+	if n != len(deliveries) {
+		t.Fatalf("DeliverDue sent %d deliveries, want %d", n, len(deliveries))
+	}
+
+	return service
+}
+
+func articlePublished(id uint64, attempts int) *conduit.WebhookDelivery {
+	return &conduit.WebhookDelivery{
+		ID:       id,
+		Event:    conduit.WebhookArticlePublished,
+		Payload:  json.RawMessage(`{"slug":"hello-world"}`),
+		Status:   conduit.DeliveryPending,
+		Attempts: attempts,
+	}
+}
+
+func TestDeliverDueSignsRequests(t *testing.T) {
+	rc := startReceiver(t, http.StatusNoContent)
+	service := rc.dispatch(t, nil, articlePublished(42, 0))
+	got, body := rc.got, rc.body
+
+	if v := got.Header.Get(EventHeader); v != conduit.WebhookArticlePublished {
+		t.Errorf("%s = %q, want %q", EventHeader, v, conduit.WebhookArticlePublished)
+	}
+
+	if v := got.Header.Get(DeliveryHeader); v != "42" {
+		t.Errorf("%s = %q, want %q", DeliveryHeader, v, "42")
+	}
+
+	timestamp, err := strconv.ParseInt(got.Header.Get(TimestampHeader), 10, 64)
+	if err != nil || timestamp != sentAt {
+		t.Errorf("%s = %q, want %d", TimestampHeader, got.Header.Get(TimestampHeader), sentAt)
+	}
+
+	if string(body) != `{"slug":"hello-world"}` {
+		t.Errorf("body = %s", body)
+	}
+
+	signature := got.Header.Get(SignatureHeader)
+	if !Verify("s3cret", timestamp, body, signature) {
+		t.Errorf("%s = %q does not verify", SignatureHeader, signature)
+	}
+
+	if Verify("other", timestamp, body, signature) {
+		t.Errorf("%s verifies with the wrong secret", SignatureHeader)
+	}
+
+	if len(service.recorded) != 1 {
+		t.Fatalf("recorded %d attempts, want 1", len(service.recorded))
+	}
+
+	if r := service.recorded[0]; !r.attempt.Succeeded() || r.retryAt != nil {
+		t.Errorf("recorded %+v, retry at %v; want a success", r.attempt, r.retryAt)
+	}
+}
+
+func TestDeliverDueBacksOff(t *testing.T) {
+	rc := startReceiver(t, http.StatusInternalServerError)
+
+	for attempts, want := range map[int]time.Duration{
+		0: 30 * time.Second,
+		1: time.Minute,
+		2: 2 * time.Minute,
+		6: 32 * time.Minute,
+	} {
+		r := rc.dispatch(t, nil, articlePublished(1, attempts)).recorded[0]
+
+		if r.attempt.Succeeded() || r.attempt.StatusCode != http.StatusInternalServerError {
+			t.Errorf("attempt %d: recorded %+v, want a 500 failure", attempts+1, r.attempt)
+		}
+
+		if r.retryAt == nil {
+			t.Errorf("attempt %d: delivery is dead, want a retry", attempts+1)
+		} else if wait := r.retryAt.Sub(time.Unix(sentAt, 0)); wait != want {
+			t.Errorf("attempt %d: retry in %v, want %v", attempts+1, wait, want)
+		}
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	d := NewDispatcher(&fakeWebhookService{})
+
+	if got := d.backoff(30); got != d.MaxBackoff {
+		t.Errorf("backoff(30) = %v, want %v", got, d.MaxBackoff)
+	}
+}
+
+func TestDeliverDueMovesToDead(t *testing.T) {
+	rc := startReceiver(t, http.StatusBadGateway)
+
+	if r := rc.dispatch(t, nil, articlePublished(1, 7)).recorded[0]; r.retryAt != nil || r.attempt.Err == nil {
+		t.Errorf("last attempt recorded %+v, retry at %v; want dead", r.attempt, r.retryAt)
+	}
+}
+
+func TestDeliverDueLeasesWholeBatch(t *testing.T) {
+	var d *Dispatcher
+	service := startReceiver(t, http.StatusOK).dispatch(t, func(configured *Dispatcher) {
+		d = configured
+		d.Client.Timeout = 10 * time.Second
+		d.Lease = time.Minute
+	})
+
+	if want := time.Duration(d.BatchSize) * d.Client.Timeout; service.lease < want {
+		t.Errorf("claimed with lease %v, want at least %v", service.lease, want)
+	}
+}
+
+func TestNewClientRefusesLoopback(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	_, err := NewClient(time.Second).Get(receiver.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Get(%s) error = %v, want %v", receiver.URL, err, ErrForbiddenAddress)
+	}
+}